@@ -31,5 +31,6 @@ replace go1f => ./
 require (
 	github.com/golang-jwt/jwt v3.2.2+incompatible
 	github.com/golang-jwt/jwt/v5 v5.2.2
+	github.com/lib/pq v1.12.3
 	go1f v0.0.0
 )
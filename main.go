@@ -1,7 +1,9 @@
 package main
 
 import (
+	"context"
 	"fmt"
+	"os"
 
 	"go1f/pkg/db"
 	"go1f/pkg/server"
@@ -9,6 +11,21 @@ import (
 
 func main() {
 
+	if len(os.Args) > 1 && os.Args[1] == "migrate" {
+		if err := migrate(os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка миграции БД: %s\n", err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && (os.Args[1] == "backup" || os.Args[1] == "restore") {
+		if err := backup(os.Args[1], os.Args[2:]); err != nil {
+			fmt.Fprintf(os.Stderr, "Ошибка команды %s: %s\n", os.Args[1], err.Error())
+			os.Exit(1)
+		}
+		return
+	}
+
 	err := db.Init()
 	if err != nil {
 		fmt.Printf("Ошибка подключения к БД: %s", err.Error())
@@ -22,3 +39,77 @@ func main() {
 	}
 
 }
+
+// migrate выполняет команду "migrate [status|dry-run|up]" управления схемой базы данных без запуска
+// сервера: status (по умолчанию) выводит версию схемы и неприменённые миграции, dry-run проверяет
+// применение миграций с откатом транзакции, up применяет миграции. При ошибке, в том числе если схема
+// базы данных новее приложения, процесс завершается с кодом 1.
+func migrate(args []string) error {
+	mode := "status"
+	if len(args) > 0 {
+		mode = args[0]
+	}
+	if err := db.Open(); err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch mode {
+	case "status":
+		status, err := db.Status()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Версия схемы БД: %d, последняя известная: %d\n", status.Current, status.Latest)
+		if status.Current > status.Latest {
+			return db.ErrSchemaTooNew
+		}
+		for _, m := range status.Pending {
+			fmt.Printf("Ожидает применения: %s\n", m)
+		}
+	case "dry-run", "up":
+		applied, err := db.Migrate(mode == "dry-run")
+		if err != nil {
+			return err
+		}
+		if len(applied) == 0 {
+			fmt.Println("Схема БД актуальна")
+		}
+		for _, m := range applied {
+			if mode == "dry-run" {
+				fmt.Printf("Будет применена: %s\n", m)
+			} else {
+				fmt.Printf("Применена: %s\n", m)
+			}
+		}
+	default:
+		return fmt.Errorf("неизвестная команда '%s' (status, dry-run или up)", mode)
+	}
+	return nil
+}
+
+// backup выполняет команды "backup <файл>" и "restore <файл>" без запуска сервера: backup записывает
+// согласованный снимок базы данных в новый файл, restore проверяет схему снимка и заменяет им файл базы
+// данных. Восстановление выполняется при остановленном сервере. При ошибке процесс завершается с кодом 1.
+func backup(cmd string, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("ожидается путь к файлу снимка: %s <файл>", cmd)
+	}
+	if cmd == "restore" {
+		version, err := db.RestoreSnapshot(args[0])
+		if err != nil {
+			return err
+		}
+		fmt.Printf("БД восстановлена из '%s', версия схемы: %d\n", args[0], version)
+		return nil
+	}
+	if err := db.Open(); err != nil {
+		return err
+	}
+	defer db.Close()
+	if err := db.Backup(context.Background(), args[0]); err != nil {
+		return err
+	}
+	fmt.Printf("Снимок БД записан в '%s'\n", args[0])
+	return nil
+}
@@ -9,6 +9,7 @@ import (
 	"time"
 
 	"go1f/pkg/db"
+	"go1f/pkg/repeat"
 )
 
 // addTaskHandler обрабатывает POST-запрос, в теле которого передан экземпляр структуры задачи в
@@ -20,29 +21,41 @@ func addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var jsID JsonID
 	_, err := buf.ReadFrom(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
 	if err = json.Unmarshal(buf.Bytes(), &task); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
 	if task.Title == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, fmt.Errorf("не указан заголовок задачи"))
+		writeErr(w, fmt.Errorf("не указан заголовок задачи"), http.StatusBadRequest)
 		return
 	}
-	if err = checkDate(&task); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+	start := task.Date
+	if err = checkDate(&task, nil); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
-	id, err := db.AddTask(&task)
+	if len(task.Repeat) > 0 {
+		rule, err := repeat.Parse(task.Repeat)
+		if err != nil {
+			writeErr(w, err, http.StatusBadRequest)
+			return
+		}
+		if rrule, ok := rule.(*repeat.RRule); ok && task.Remaining == 0 && rrule.Count > 0 {
+			// COUNT отсчитывается от исходной даты задачи (DTSTART), а не от даты, на которую её
+			// перенёс checkDate.
+			task.Remaining = rrule.Count - skippedDates(rule, start, task.Date)
+		}
+	}
+	if err = checkSeries(&task); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	id, err := db.AddTask(r.Context(), &task)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeDbErr(w, err)
 		return
 	}
 	jsID.ID = strconv.Itoa(int(id))
@@ -50,10 +63,18 @@ func addTaskHandler(w http.ResponseWriter, r *http.Request) {
 	writeJson(w, jsID)
 }
 
-// checkDate проверяет на корректность даты задачи, переданной в task.
-func checkDate(task *db.Task) error {
-	now := time.Now()
-	now = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+// checkDate проверяет на корректность дату, время, часовой пояс и правило повторения задачи, переданной
+// в task. Текущая дата определяется в часовом поясе задачи. Прошедшая дата заменяется текущей либо, для
+// повторяющейся задачи, следующей датой по правилу; текущая дата остаётся без изменений. Дата
+// повторяющейся задачи не может совпадать с одной из её исключённых дат except (см. taskExclusions).
+func checkDate(task *db.Task, except map[string]bool) error {
+	if err := checkTime(task); err != nil {
+		return err
+	}
+	now, err := today(task.TZ)
+	if err != nil {
+		return err
+	}
 	if len(task.Date) == 0 {
 		task.Date = now.Format(db.DateString)
 	}
@@ -61,15 +82,68 @@ func checkDate(task *db.Task) error {
 	if err != nil {
 		return err
 	}
-	if !t.After(now) {
+	if len(task.Repeat) > 0 {
+		if _, err := repeat.Parse(task.Repeat); err != nil {
+			return err
+		}
+	}
+	if t.Before(now) {
 		if len(task.Repeat) == 0 {
 			task.Date = now.Format(db.DateString)
 			return nil
 		}
-		task.Date, err = nextDate(now, task.Date, task.Repeat)
+		task.Date, err = nextDateExcluding(now, task.Date, task.Repeat, except)
+		if err != nil {
+			return err
+		}
+	} else if except[task.Date] {
+		task.Date, err = nextDateExcluding(t, task.Date, task.Repeat, except)
 		if err != nil {
 			return err
 		}
 	}
 	return nil
 }
+
+// skippedDates возвращает количество дат серии правила rule, начатой в дату задачи from, которые
+// предшествуют дате to: саму дату from и следующие за ней даты правила. Если from не раньше to,
+// возвращает 0.
+func skippedDates(rule repeat.Rule, from, to string) int {
+	start, err := time.Parse(db.DateString, from)
+	if err != nil || from >= to {
+		return 0
+	}
+	end, err := time.Parse(db.DateString, to)
+	if err != nil {
+		return 0
+	}
+	series := rule.From(start)
+	n := 1
+	for d := series.Next(start); !d.IsZero() && d.Before(end); d = series.Next(d) {
+		n++
+	}
+	return n
+}
+
+// checkSeries проверяет условия окончания серии повторений задачи, переданной в task: дату окончания
+// (Until) и оставшееся количество повторений (Remaining). Дата задачи должна быть уже проверена checkDate.
+func checkSeries(task *db.Task) error {
+	if task.Remaining < 0 {
+		return fmt.Errorf("недопустимое количество повторений: %d", task.Remaining)
+	}
+	if len(task.Until) == 0 && task.Remaining == 0 {
+		return nil
+	}
+	if len(task.Repeat) == 0 {
+		return fmt.Errorf("условия окончания допустимы только для повторяющейся задачи")
+	}
+	if len(task.Until) > 0 {
+		if _, err := time.Parse(db.DateString, task.Until); err != nil {
+			return err
+		}
+		if task.Date > task.Until {
+			return fmt.Errorf("дата задачи %s позже даты окончания серии %s", task.Date, task.Until)
+		}
+	}
+	return nil
+}
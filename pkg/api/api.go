@@ -4,7 +4,10 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
+
+	"go1f/pkg/db"
 )
 
 // WebDir содержит путь к файлам фронтэнда.
@@ -15,25 +18,61 @@ type JsonID struct {
 	ID string `json:"id,omitempty"`
 }
 
+// StatusClientClosedRequest содержит нестандартный код ответа 499 (принят в nginx) для запроса,
+// прерванного клиентом до получения ответа.
+const StatusClientClosedRequest = 499
+
 // JsonErr обёртка над err для удобства вывода в формате json.
 type JsonErr struct {
 	Err string `json:"error,omitempty"`
 }
 
-// Init инициализирует хендлеры.
-func Init() {
+// Init загружает календарь праздничных дней и часовой пояс по умолчанию и инициализирует хендлеры.
+func Init() error {
+	if err := loadHolidays(); err != nil {
+		return err
+	}
+	if err := loadLocation(); err != nil {
+		return err
+	}
+
 	http.Handle("/", http.FileServer(http.Dir(WebDir)))
 
 	http.HandleFunc("/api/nextdate", nextDayHandler)
 
+	http.HandleFunc("/api/nextdates", previewHandler)
+
+	http.HandleFunc("/api/rrule", rruleHandler)
+
+	http.HandleFunc("/api/describe", describeHandler)
+
 	http.HandleFunc("/api/task", auth(taskHandler))
 
 	http.HandleFunc("/api/tasks", auth(tasksHandler))
 
+	http.HandleFunc("/api/tasks/syntax", searchSyntaxHandler)
+
 	http.HandleFunc("/api/task/done", auth(doneHandler))
 
+	http.HandleFunc("/api/task/exclusions", auth(exclusionsHandler))
+
+	http.HandleFunc("/api/task/history", auth(taskHistoryHandler))
+
+	http.HandleFunc("/api/task/revisions", auth(revisionsHandler))
+
+	http.HandleFunc("/api/task/revert", auth(revertHandler))
+
+	http.HandleFunc("/api/completions", auth(completionsHandler))
+
+	http.HandleFunc("/api/trash", auth(trashHandler))
+
+	http.HandleFunc("/api/trash/restore", auth(restoreHandler))
+
+	http.HandleFunc("/api/backup", auth(backupHandler))
+
 	http.HandleFunc("/api/signin", passCheckHandler)
 
+	return nil
 }
 
 // TaskHandler распределяет обращение по адресу в соответствии с методом запроса.
@@ -65,9 +104,39 @@ func writeJson(w http.ResponseWriter, answer any) {
 	w.Write(resp)
 }
 
+// writeErr записывает в ответ w ошибку err в json-формате с кодом ответа status. Заголовок Content-Type
+// устанавливается до кода ответа: после вызова WriteHeader изменить заголовки уже нельзя.
+func writeErr(w http.ResponseWriter, err error, status int) {
+	w.Header().Set("Content-Type", "application/json; charset=UTF-8")
+	w.WriteHeader(status)
+	writeJsonErr(w, err)
+}
+
 // writeJsonErr конвертирует переданную в err ошибку в json-формат и записывает в ответ w.
 func writeJsonErr(w http.ResponseWriter, err error) {
 	var jsErr JsonErr
 	jsErr.Err = err.Error()
 	writeJson(w, jsErr)
 }
+
+// writeDbErr записывает в ответ w ошибку err, полученную при обращении к базе данных, в json-формате
+// с кодом ответа: 400, если запрос недопустим (не найдена задача, неверные параметры), 409, если задача
+// изменена другим запросом, 503, если база данных занята другими соединениями, 504, если превышено время
+// выполнения операции, StatusClientClosedRequest, если клиент отменил запрос, иначе 500 - ошибка самого
+// хранилища.
+func writeDbErr(w http.ResponseWriter, err error) {
+	status := http.StatusInternalServerError
+	switch {
+	case errors.Is(err, db.ErrInvalid):
+		status = http.StatusBadRequest
+	case errors.Is(err, db.ErrConflict):
+		status = http.StatusConflict
+	case errors.Is(err, db.ErrBusy):
+		status = http.StatusServiceUnavailable
+	case errors.Is(err, db.ErrTimeout):
+		status = http.StatusGatewayTimeout
+	case errors.Is(err, db.ErrCanceled):
+		status = StatusClientClosedRequest
+	}
+	writeErr(w, err, status)
+}
@@ -28,27 +28,23 @@ func passCheckHandler(w http.ResponseWriter, r *http.Request) {
 	var buf bytes.Buffer
 	_, err := buf.ReadFrom(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
 	err = json.Unmarshal(buf.Bytes(), &pass)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
 	if len(envPass) > 0 {
 		if envPass != pass.Password {
-			w.WriteHeader(http.StatusUnauthorized)
-			writeJsonErr(w, fmt.Errorf("неверный пароль"))
+			writeErr(w, fmt.Errorf("неверный пароль"), http.StatusUnauthorized)
 			return
 		}
 		jwtToken := jwt.New(jwt.SigningMethodHS256)
 		token.Token, err = jwtToken.SignedString([]byte(envPass))
 		if err != nil {
-			w.WriteHeader(http.StatusInternalServerError)
-			writeJsonErr(w, err)
+			writeErr(w, err, http.StatusInternalServerError)
 			return
 		}
 		w.WriteHeader(http.StatusOK)
@@ -67,13 +63,11 @@ func auth(next http.HandlerFunc) http.HandlerFunc {
 			jwtNew := jwt.New(jwt.SigningMethodHS256)
 			jwtFromPass, err := jwtNew.SignedString([]byte(envPass))
 			if err != nil {
-				w.WriteHeader(http.StatusInternalServerError)
-				writeJsonErr(w, err)
+				writeErr(w, err, http.StatusInternalServerError)
 				return
 			}
 			if jwtFromCookie != jwtFromPass {
-				w.WriteHeader(http.StatusUnauthorized)
-				writeJsonErr(w, fmt.Errorf("требуется аутентификация"))
+				writeErr(w, fmt.Errorf("требуется аутентификация"), http.StatusUnauthorized)
 				return
 			}
 		}
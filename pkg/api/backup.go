@@ -0,0 +1,51 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"go1f/pkg/db"
+)
+
+// backupHandler обрабатывает GET-запрос на получение согласованной резервной копии базы данных.
+// Снимок создаётся во временном каталоге, отдаётся файлом "scheduler-<время>.db" и затем удаляется.
+// Снимок содержит все задачи, поэтому без пароля в переменной окружения TODO_PASSWORD
+// запрос отклоняется со статусом 403. В случае неудачи возвращает ошибку в json-формате.
+func backupHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	if len(envPass) == 0 {
+		writeErr(w, fmt.Errorf("резервное копирование доступно только при заданном пароле"),
+			http.StatusForbidden)
+		return
+	}
+	dir, err := os.MkdirTemp("", "scheduler-backup")
+	if err != nil {
+		writeErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	now := time.Now().UTC()
+	name := fmt.Sprintf("scheduler-%s.db", now.Format("20060102-150405"))
+	path := filepath.Join(dir, name)
+	if err := db.Backup(r.Context(), path); err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		writeErr(w, err, http.StatusInternalServerError)
+		return
+	}
+	defer file.Close()
+
+	w.Header().Set("Content-Type", "application/vnd.sqlite3")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, name))
+	http.ServeContent(w, r, name, now, file)
+}
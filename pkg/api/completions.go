@@ -0,0 +1,57 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go1f/pkg/db"
+	"go1f/pkg/filter"
+)
+
+// JsonCompletions обёртка над списком записей о выполнении задач для удобства вывода в json-формате.
+type JsonCompletions struct {
+	Completions []db.Completion `json:"completions"`
+}
+
+// completionsHandler обрабатывает GET-запрос на возврат записей о выполнении всех задач, упорядоченных
+// по времени выполнения, в json-формате. Необязательные "from" и "to" ограничивают даты выполнения
+// включительно (в тех же форматах, что и даты запроса поиска); даты отсчитываются в часовом поясе
+// из необязательного "tz" (по умолчанию - часовой пояс сервера). В случае неудачи возвращает ошибку
+// в json-формате.
+func completionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	query := r.URL.Query()
+	loc, err := location(query.Get("tz"))
+	if err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	now, err := today(query.Get("tz"))
+	if err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	var bounds [2]time.Time
+	for i, param := range []string{"from", "to"} {
+		if len(query.Get(param)) == 0 {
+			continue
+		}
+		date, err := filter.ParseDate(query.Get(param), now)
+		if err != nil {
+			writeErr(w, fmt.Errorf("параметр '%s': %w", param, err), http.StatusBadRequest)
+			return
+		}
+		// Граница to включительно: выборка ограничивается началом следующего дня.
+		bounds[i] = time.Date(date.Year(), date.Month(), date.Day()+i, 0, 0, 0, 0, loc)
+	}
+	list, err := db.Completions(r.Context(), bounds[0], bounds[1])
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, JsonCompletions{Completions: list})
+}
@@ -0,0 +1,44 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"go1f/pkg/db"
+	"go1f/pkg/repeat"
+)
+
+// describeHandler обрабатывает GET-запрос по переданным в URL "repeat" и необязательному "lang" ("ru" или
+// "en", по умолчанию "ru") на возврат описания правила повторения на естественном языке. Возвращает
+// строку с описанием. В случае неудачи возвращает ошибку.
+func describeHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	lang, err := repeat.ParseLang(r.URL.Query().Get("lang"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule, err := repeat.Parse(r.URL.Query().Get("repeat"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprint(w, repeat.Describe(rule, lang))
+}
+
+// describeTasks заполняет у задач tasks вычисляемое поле RepeatText описанием правила повторения на языке,
+// переданном в URL "lang" запроса r. Если язык не поддерживается, используется русский.
+func describeTasks(r *http.Request, tasks ...*db.Task) {
+	lang, err := repeat.ParseLang(r.URL.Query().Get("lang"))
+	if err != nil {
+		lang = repeat.LangRu
+	}
+	for _, task := range tasks {
+		if rule, err := repeat.Parse(task.Repeat); err == nil {
+			task.RepeatText = repeat.Describe(rule, lang)
+		}
+	}
+}
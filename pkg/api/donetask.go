@@ -1,43 +1,95 @@
 package api
 
 import (
-	"go1f/pkg/db"
+	"bytes"
+	"encoding/json"
+	"errors"
 	"net/http"
-	"time"
+
+	"go1f/pkg/db"
 )
 
+// JsonNote обёртка над заметкой к выполнению задачи для удобства чтения из json-формата.
+type JsonNote struct {
+	Note string `json:"note"`
+}
+
 // doneHandler обрабатывает POST-запрос по переданному в URL "id" на изменение даты задачи на
-// актуальную в базе данных, либо на удаление, если правило задачи отсутствует. В случае успешного
-// выполнения возвращает пустой json. В случае неудачи возвращает ошибку в json-формате.
+// актуальную в базе данных, либо на перемещение в корзину, если правило задачи отсутствует или серия
+// повторений исчерпана (достигнута дата окончания или количество повторений). Для задачи в режиме
+// повторения после выполнения (AfterDone) следующая дата отсчитывается от текущей даты, а не от даты
+// задачи. Текущая дата определяется в часовом поясе задачи. Выполнение записывается в историю задачи
+// в одной транзакции с переносом или удалением; в необязательном теле запроса можно передать заметку
+// к выполнению в json-формате ({"note": "..."}). Выполнение применяется, только если версия задачи
+// не изменилась с момента её чтения обработчиком, поэтому повторное нажатие или выполнение с другого
+// устройства не переносит задачу дважды: такой запрос получает 409. Необязательный заголовок If-Match
+// задаёт ожидаемую версию (ETag из getTaskHandler); при несоответствии возвращается 412. В случае
+// успешного выполнения возвращает пустой json. В случае неудачи возвращает ошибку в json-формате.
 func doneHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
-	task, err := db.GetTask(id)
+	task, err := db.GetTask(r.Context(), id)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	if _, ok := ifMatch(r, task.Version); !ok {
+		writePreconditionErr(w)
+		return
+	}
+	var body JsonNote
+	var buf bytes.Buffer
+	if _, err = buf.ReadFrom(r.Body); err == nil && buf.Len() > 0 {
+		err = json.Unmarshal(buf.Bytes(), &body)
+	}
+	if err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	done := &db.Completion{TaskID: task.ID, Title: task.Title, Date: task.Date, Note: body.Note}
+	if len(task.Repeat) == 0 || task.Remaining == 1 {
+		completeTask(w, r, done, task.Version, nil)
+		return
+	}
+	except, err := taskExclusions(r.Context(), task)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	now, err := today(task.TZ)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
-	if len(task.Repeat) == 0 {
-		err := db.DeleteTask(task.ID)
-		if err != nil {
-			w.WriteHeader(http.StatusBadRequest)
-			writeJsonErr(w, err)
-			return
-		}
-		writeJson(w, map[string]interface{}{})
+	dstart := task.Date
+	if task.AfterDone {
+		// В режиме повторения после выполнения следующая дата отсчитывается от даты выполнения.
+		dstart = now.Format(db.DateString)
+	}
+	next, err := nextDateExcluding(now, dstart, task.Repeat, except)
+	if errors.Is(err, errNoNextDate) || (err == nil && len(task.Until) > 0 && next > task.Until) {
+		completeTask(w, r, done, task.Version, nil)
 		return
 	}
-	task.Date, err = nextDate(time.Now().UTC(), task.Date, task.Repeat)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
+		return
 	}
-	err = db.UpdateTask(task)
+	task.Date = next
+	if task.Remaining > 0 {
+		task.Remaining--
+	}
+	completeTask(w, r, done, task.Version, task)
+}
+
+// completeTask записывает выполнение done в историю и переносит задачу версии version на следующую
+// дату полями next либо, если next равно nil, перемещает в корзину задачу, у которой не осталось
+// повторений. В случае успешного выполнения возвращает пустой json. В случае неудачи возвращает ошибку
+// в json-формате.
+func completeTask(w http.ResponseWriter, r *http.Request, done *db.Completion, version int64, next *db.Task) {
+	err := db.CompleteTask(r.Context(), done, version, next)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeVersionErr(w, r, err)
 		return
 	}
-	w.WriteHeader(http.StatusOK)
 	writeJson(w, map[string]interface{}{})
 }
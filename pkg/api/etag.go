@@ -0,0 +1,49 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"go1f/pkg/db"
+)
+
+// etag возвращает значение заголовка ETag для задачи с версией version.
+func etag(version int64) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// ifMatch проверяет заголовок If-Match запроса r по текущей версии задачи version. Возвращает given -
+// задан ли заголовок, и ok - соответствует ли он версии: заголовок содержит список ETag через запятую
+// или "*" - любую версию. Слабые ETag ("W/...") не соответствуют ни одной версии.
+func ifMatch(r *http.Request, version int64) (given, ok bool) {
+	header := r.Header.Get("If-Match")
+	if len(header) == 0 {
+		return false, true
+	}
+	for _, tag := range strings.Split(header, ",") {
+		tag = strings.TrimSpace(tag)
+		if tag == "*" || tag == etag(version) {
+			return true, true
+		}
+	}
+	return true, false
+}
+
+// writePreconditionErr записывает в ответ w ошибку несоответствия заголовка If-Match версии задачи
+// с кодом ответа 412.
+func writePreconditionErr(w http.ResponseWriter) {
+	writeErr(w, fmt.Errorf("версия задачи не соответствует заголовку If-Match"), http.StatusPreconditionFailed)
+}
+
+// writeVersionErr записывает в ответ w ошибку err изменения задачи. Если задача изменена другим запросом
+// (db.ErrConflict), а клиент передал ожидаемую версию в заголовке If-Match, отвечает кодом 412, иначе
+// обрабатывает ошибку как writeDbErr.
+func writeVersionErr(w http.ResponseWriter, r *http.Request, err error) {
+	if errors.Is(err, db.ErrConflict) && len(r.Header.Get("If-Match")) > 0 {
+		writePreconditionErr(w)
+		return
+	}
+	writeDbErr(w, err)
+}
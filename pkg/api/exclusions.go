@@ -0,0 +1,152 @@
+package api
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"go1f/pkg/db"
+)
+
+// JsonDates обёртка над списком дат для удобства вывода в json-формате.
+type JsonDates struct {
+	Dates []string `json:"dates"`
+}
+
+// exclusionsHandler распределяет обращение по адресу в соответствии с методом запроса.
+func exclusionsHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet:
+		getExclusionsHandler(w, r)
+	case r.Method == http.MethodPost:
+		addExclusionHandler(w, r)
+	case r.Method == http.MethodDelete:
+		deleteExclusionHandler(w, r)
+	default:
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+	}
+}
+
+// getExclusionsHandler обрабатывает GET-запрос по переданному в URL "id" на возврат списка исключённых
+// дат задачи в json-формате. В случае неудачи возвращает ошибку в json-формате.
+func getExclusionsHandler(w http.ResponseWriter, r *http.Request) {
+	task, err := db.GetTask(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	dates, err := db.Exclusions(r.Context(), task.ID)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, JsonDates{Dates: dates})
+}
+
+// addExclusionHandler обрабатывает POST-запрос по переданным в URL "id" и "date" на добавление даты
+// в список исключённых дат повторяющейся задачи. Если дата задачи совпадает с исключённой, задача
+// переносится на следующую дату в той же транзакции. Если задача изменена другим запросом после её
+// чтения, дата не добавляется и возвращается 409. В случае успешного выполнения возвращает пустой json.
+// В случае неудачи возвращает ошибку в json-формате.
+func addExclusionHandler(w http.ResponseWriter, r *http.Request) {
+	task, err := db.GetTask(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	date := r.URL.Query().Get("date")
+	if _, err := time.Parse(db.DateString, date); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	if len(task.Repeat) == 0 {
+		writeErr(w, fmt.Errorf("исключённые даты допустимы только для повторяющейся задачи"), http.StatusBadRequest)
+		return
+	}
+	var next *db.Task
+	if task.Date == date {
+		except, err := taskExclusions(r.Context(), task)
+		if err != nil {
+			writeDbErr(w, err)
+			return
+		}
+		except[date] = true
+		moved := *task
+		if err := checkDate(&moved, except); err != nil {
+			writeErr(w, err, http.StatusBadRequest)
+			return
+		}
+		next = &moved
+	}
+	if err := db.AddExclusion(r.Context(), task.ID, date, task.Version, next); err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, map[string]interface{}{})
+}
+
+// deleteExclusionHandler обрабатывает DELETE-запрос по переданным в URL "id" и "date" на удаление даты
+// из списка исключённых дат задачи. В случае успешного выполнения возвращает пустой json. В случае
+// неудачи возвращает ошибку в json-формате.
+func deleteExclusionHandler(w http.ResponseWriter, r *http.Request) {
+	task, err := db.GetTask(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	if err := db.DeleteExclusion(r.Context(), task.ID, r.URL.Query().Get("date")); err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, map[string]interface{}{})
+}
+
+// taskExclusions возвращает множество исключённых дат задачи task. Для новой или неповторяющейся
+// задачи множество пустое.
+func taskExclusions(ctx context.Context, task *db.Task) (map[string]bool, error) {
+	except := make(map[string]bool)
+	if len(task.ID) == 0 || len(task.Repeat) == 0 {
+		return except, nil
+	}
+	dates, err := db.Exclusions(ctx, task.ID)
+	if err != nil {
+		return except, err
+	}
+	for _, d := range dates {
+		except[d] = true
+	}
+	return except, nil
+}
+
+// parseExclusions конвертирует строку s с перечисленными через запятую датами в формате "20060102"
+// в множество исключённых дат. В случае неудачи возвращает ошибку.
+func parseExclusions(s string) (map[string]bool, error) {
+	except := make(map[string]bool)
+	if len(s) == 0 {
+		return except, nil
+	}
+	for _, d := range strings.Split(s, ",") {
+		if _, err := time.Parse(db.DateString, d); err != nil {
+			return except, err
+		}
+		except[d] = true
+	}
+	return except, nil
+}
+
+// nextDateExcluding возвращает то же, что и nextDate, но пропускает даты из множества except.
+// Отсчёт интервалов правила по-прежнему ведётся от даты старта (dstart).
+func nextDateExcluding(now time.Time, dstart string, repeat string, except map[string]bool) (string, error) {
+	next, err := nextDate(now, dstart, repeat)
+	for err == nil && except[next] {
+		// Следующая дата всегда позже исключённой, поэтому количество итераций не превышает len(except).
+		var after time.Time
+		after, err = time.Parse(db.DateString, next)
+		if err == nil {
+			next, err = nextDate(after, dstart, repeat)
+		}
+	}
+	return next, err
+}
@@ -6,16 +6,18 @@ import (
 	"go1f/pkg/db"
 )
 
-// getTaskHandler обрабатывает GET-запрос по переданному в URL "id" на возврат задачи в json-формате.
-// В случае неудачи возвращает ошибку в json-формате.
+// getTaskHandler обрабатывает GET-запрос по переданному в URL "id" на возврат задачи в json-формате
+// с описанием правила повторения на языке из необязательного "lang". Версия задачи возвращается
+// в заголовке ETag для условных запросов с If-Match. В случае неудачи возвращает ошибку в json-формате.
 func getTaskHandler(w http.ResponseWriter, r *http.Request) {
 	id := r.URL.Query().Get("id")
-	task, err := db.GetTask(id)
+	task, err := db.GetTask(r.Context(), id)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeDbErr(w, err)
 		return
 	}
+	describeTasks(r, task)
+	w.Header().Set("ETag", etag(task.Version))
 	w.WriteHeader(http.StatusOK)
 	writeJson(w, task)
 }
@@ -0,0 +1,29 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"go1f/pkg/db"
+)
+
+// taskHistoryHandler обрабатывает GET-запрос по переданному в URL "id" на возврат истории выполнения
+// задачи, упорядоченной по времени выполнения, в json-формате. История доступна и для задач в корзине,
+// и для окончательно удалённых задач. В случае неудачи возвращает ошибку в json-формате.
+func taskHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, fmt.Errorf("не указан идентификатор"), http.StatusBadRequest)
+		return
+	}
+	list, err := db.TaskCompletions(r.Context(), id)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, JsonCompletions{Completions: list})
+}
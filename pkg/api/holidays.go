@@ -0,0 +1,24 @@
+package api
+
+import (
+	"context"
+
+	"go1f/pkg/db"
+	"go1f/pkg/repeat"
+)
+
+// loadHolidays загружает праздничные дни из таблицы holidays базы данных в календарь, используемый
+// правилами рабочих дней. Календарь загружается один раз при запуске сервера, поэтому изменения
+// файла TODO_HOLIDAYS вступают в силу только после перезапуска.
+func loadHolidays() error {
+	list, err := db.Holidays(context.Background())
+	if err != nil {
+		return err
+	}
+	holidays := make(repeat.HolidaySet, len(list))
+	for _, h := range list {
+		holidays[h.Date] = true
+	}
+	repeat.DefaultCalendar = holidays
+	return nil
+}
@@ -0,0 +1,81 @@
+package api
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"go1f/pkg/db"
+)
+
+const (
+	DefaultPreviewDates = 10  // Количество дат, возвращаемых по умолчанию при предпросмотре правила
+	MaxPreviewDates     = 100 // Максимальное количество дат, возвращаемых при предпросмотре правила
+)
+
+// previewHandler обрабатывает GET-запрос по переданным в URL "date", "repeat" и необязательным "now",
+// "tz", "count", "until" и "except" на возврат ближайших дат задачи в json-формате. Возвращается не более
+// "count" дат (DefaultPreviewDates, если не указаны ни "count", ни "until") и не позже "until", но
+// в любом случае не более MaxPreviewDates. В случае неудачи возвращает ошибку в json-формате.
+func previewHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	dates, err := previewDates(r)
+	if err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	writeJson(w, JsonDates{Dates: dates})
+}
+
+// previewDates разбирает параметры запроса r и возвращает соответствующий им список дат задачи.
+func previewDates(r *http.Request) ([]string, error) {
+	query := r.URL.Query()
+	date := query.Get("date")
+	repeat := query.Get("repeat")
+	now, err := parseNow(query.Get("now"), query.Get("tz"))
+	if err != nil {
+		return nil, err
+	}
+	except, err := parseExclusions(query.Get("except"))
+	if err != nil {
+		return nil, err
+	}
+	until := query.Get("until")
+	if len(until) > 0 {
+		if _, err := time.Parse(db.DateString, until); err != nil {
+			return nil, err
+		}
+	}
+	count := MaxPreviewDates
+	if len(query.Get("count")) > 0 {
+		count, err = strconv.Atoi(query.Get("count"))
+		if err != nil {
+			return nil, err
+		}
+		if count < 1 || count > MaxPreviewDates {
+			return nil, fmt.Errorf("недопустимое количество дат: %d!(допускается от 1 до %d)", count, MaxPreviewDates)
+		}
+	} else if len(until) == 0 {
+		count = DefaultPreviewDates
+	}
+
+	dates := make([]string, 0, count)
+	next, err := nextDateExcluding(now, date, repeat, except)
+	for err == nil && len(dates) < count && (len(until) == 0 || next <= until) {
+		dates = append(dates, next)
+		var after time.Time
+		after, err = time.Parse(db.DateString, next)
+		if err == nil {
+			next, err = nextDateExcluding(after, date, repeat, except)
+		}
+	}
+	if err != nil && !errors.Is(err, errNoNextDate) {
+		return nil, err
+	}
+	return dates, nil
+}
@@ -0,0 +1,65 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+
+	"go1f/pkg/db"
+)
+
+// JsonRevision обёртка над ревизией задачи со списком изменённых полей.
+type JsonRevision struct {
+	db.Revision
+	Changes []db.FieldChange `json:"changes"`
+}
+
+// JsonRevisions обёртка над историей изменений задачи для удобства вывода в json-формате.
+type JsonRevisions struct {
+	Revisions []JsonRevision `json:"revisions"`
+}
+
+// revisionsHandler обрабатывает GET-запрос по переданному в URL "id" на возврат истории изменений задачи,
+// упорядоченной по времени изменения, в json-формате. Каждая ревизия содержит состояния задачи до
+// и после изменения и список изменённых полей. В случае неудачи возвращает ошибку в json-формате.
+func revisionsHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	if id == "" {
+		writeErr(w, fmt.Errorf("не указан идентификатор"), http.StatusBadRequest)
+		return
+	}
+	list, err := db.TaskRevisions(r.Context(), id)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	res := JsonRevisions{Revisions: make([]JsonRevision, 0, len(list))}
+	for _, rev := range list {
+		res.Revisions = append(res.Revisions, JsonRevision{Revision: rev, Changes: rev.Changes()})
+	}
+	writeJson(w, res)
+}
+
+// revertHandler обрабатывает POST-запрос по переданным в URL "id" и "revision" на возврат полей задачи
+// к состоянию после указанной ревизии. Задача из корзины при этом восстанавливается. В случае успешного
+// выполнения возвращает пустой json. В случае неудачи возвращает ошибку в json-формате.
+func revertHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	id := r.URL.Query().Get("id")
+	revision := r.URL.Query().Get("revision")
+	if id == "" || revision == "" {
+		writeErr(w, fmt.Errorf("не указан идентификатор задачи или ревизии"), http.StatusBadRequest)
+		return
+	}
+	if err := db.RevertTask(r.Context(), id, revision); err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, map[string]interface{}{})
+}
@@ -0,0 +1,35 @@
+package api
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"go1f/pkg/db"
+	"go1f/pkg/repeat"
+)
+
+// rruleHandler обрабатывает GET-запрос по переданным в URL "date" и "repeat" на конвертацию правила
+// повторения в формат RRULE. Возвращает строку с правилом. В случае неудачи возвращает ошибку.
+func rruleHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	date, err := time.Parse(db.DateString, r.URL.Query().Get("date"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rule, err := repeat.Parse(r.URL.Query().Get("repeat"))
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	rrule, err := repeat.ToRRule(rule, date)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	fmt.Fprint(w, rrule)
+}
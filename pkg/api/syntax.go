@@ -0,0 +1,22 @@
+package api
+
+import (
+	"net/http"
+
+	"go1f/pkg/filter"
+)
+
+// JsonOperators обёртка над списком операторов языка запросов для удобства вывода в json-формате.
+type JsonOperators struct {
+	Operators []filter.Operator `json:"operators"`
+}
+
+// searchSyntaxHandler обрабатывает GET-запрос на возврат описания операторов языка запросов поиска
+// задач (параметр "search" у "/api/tasks") в json-формате.
+func searchSyntaxHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	writeJson(w, JsonOperators{Operators: filter.Operators})
+}
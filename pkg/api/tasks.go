@@ -1,31 +1,150 @@
 package api
 
 import (
+	"fmt"
 	"net/http"
+	"net/url"
+	"strconv"
+	"time"
 
 	"go1f/pkg/db"
+	"go1f/pkg/filter"
 )
 
-// TasksResp обёртка над слайсом задач для удобства вывода в json-фомате.
+// TasksResp обёртка над слайсом задач для удобства вывода в json-фомате. Total (количество всех найденных
+// задач) и NextCursor (курсор следующей страницы) выводятся только при постраничном запросе.
 type TasksResp struct {
-	Tasks []*db.Task `json:"tasks"`
+	Tasks      []*db.Task `json:"tasks"`
+	Total      *int       `json:"total,omitempty"`
+	NextCursor string     `json:"next_cursor,omitempty"`
 }
 
 var maxEntries = 10 // максимальное количество выводимых записей
 
+// MaxPageSize содержит максимальный размер страницы, который можно запросить в "limit".
+const MaxPageSize = 100
+
+// Значения параметра "kind" списка задач.
+const (
+	KindRecurring = "recurring" // Повторяющиеся задачи
+	KindOnce      = "once"      // Однократные задачи
+)
+
 // tasksHandler обрабатывает GET-запрос на возврат списка задач, отсортированных по степени актуальности
-// во времени, в json-формате. Количество ограничено значением maxEntries. В случае неудачи возвращает ошибку в
-// json-формате.
+// во времени, в json-формате. В необязательном "search" передаётся запрос поиска на языке пакета filter
+// (описание операторов возвращает searchSyntaxHandler); даты today, tomorrow и yesterday отсчитываются
+// от текущей даты в часовом поясе по умолчанию. Если запрос содержит слова для поиска, задачи упорядочиваются
+// по релевантности и содержат фрагмент текста с выделенными найденными словами. Правила повторения задач
+// описываются на языке из необязательного "lang".
+//
+// Необязательные параметры сужают выборку: "from" и "to" - границы дат задач включительно (в тех же
+// форматах, что и даты запроса поиска), "kind" - KindRecurring или KindOnce, "overdue=true" - только
+// просроченные задачи, дата которых раньше текущей. В "sort" передаётся порядок задач (см. db.SortOrders),
+// по умолчанию - по релевантности при поиске по словам и по дате в остальных случаях.
+//
+// Количество задач ограничено значением maxEntries или необязательным "limit" (не более MaxPageSize).
+// Если передан "limit" или "cursor", запрос считается постраничным: в ответ добавляются "total" и, если
+// страница не последняя, "next_cursor", который передаётся в "cursor" для получения следующей страницы.
+// В случае неудачи возвращает ошибку в json-формате.
 func tasksHandler(w http.ResponseWriter, r *http.Request) {
-	search := r.URL.Query().Get("search")
-	tasks, err := db.Tasks(maxEntries, search)
+	listTasks(w, r, false)
+}
+
+// listTasks записывает в ответ w список действующих задач или, если trash истинно, задач корзины,
+// выбранных по параметрам запроса r так, как описано у tasksHandler.
+func listTasks(w http.ResponseWriter, r *http.Request, trash bool) {
+	query := r.URL.Query()
+	now, err := today("")
+	if err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	f, err := listFilter(query, now)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
-	writeJson(w, TasksResp{
-		Tasks: tasks,
+	limit := maxEntries
+	if len(query.Get("limit")) > 0 {
+		limit, err = strconv.Atoi(query.Get("limit"))
+		if err != nil || limit < 1 || limit > MaxPageSize {
+			writeErr(w, fmt.Errorf("недопустимый размер страницы '%s' (от 1 до %d)", query.Get("limit"), MaxPageSize), http.StatusBadRequest)
+			return
+		}
+	}
+	page, err := db.Tasks(r.Context(), db.TaskQuery{
+		Filter: f,
+		Sort:   db.SortOrder(query.Get("sort")),
+		Limit:  limit,
+		Cursor: query.Get("cursor"),
+		Trash:  trash,
 	})
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	describeTasks(r, page.Tasks...)
+	resp := TasksResp{
+		Tasks: page.Tasks,
+	}
+	if query.Has("limit") || query.Has("cursor") {
+		resp.Total = &page.Total
+		resp.NextCursor = page.NextCursor
+	}
+	writeJson(w, resp)
+
+}
 
+// listFilter возвращает дерево условий выборки задач: условия запроса поиска "search" вместе
+// с условиями параметров "from", "to", "kind" и "overdue". Даты отсчитываются от текущей даты now.
+func listFilter(query url.Values, now time.Time) (filter.Node, error) {
+	f, err := filter.Parse(query.Get("search"), now)
+	if err != nil {
+		return nil, err
+	}
+	var nodes filter.And
+	if f != nil {
+		nodes = append(nodes, f)
+	}
+	// Границы включительно: from - дата не раньше указанной, to - не позже.
+	bounds := []struct {
+		param string
+		field filter.Field
+	}{{"from", filter.FieldBefore}, {"to", filter.FieldAfter}}
+	for _, b := range bounds {
+		if len(query.Get(b.param)) == 0 {
+			continue
+		}
+		date, err := filter.ParseDate(query.Get(b.param), now)
+		if err != nil {
+			return nil, fmt.Errorf("параметр '%s': %w", b.param, err)
+		}
+		nodes = append(nodes, filter.Not{Node: filter.Term{Field: b.field, Value: date.Format(filter.DateFormat)}})
+	}
+	recurring := filter.Term{Field: filter.FieldRepeat, Value: filter.AnyRepeat}
+	switch query.Get("kind") {
+	case "":
+	case KindRecurring:
+		nodes = append(nodes, recurring)
+	case KindOnce:
+		nodes = append(nodes, filter.Not{Node: recurring})
+	default:
+		return nil, fmt.Errorf("недопустимый вид задач '%s' (%s или %s)", query.Get("kind"), KindRecurring, KindOnce)
+	}
+	if len(query.Get("overdue")) > 0 {
+		overdue, err := strconv.ParseBool(query.Get("overdue"))
+		if err != nil {
+			return nil, fmt.Errorf("недопустимое значение параметра 'overdue': '%s'", query.Get("overdue"))
+		}
+		if overdue {
+			nodes = append(nodes, filter.Term{Field: filter.FieldBefore, Value: now.Format(filter.DateFormat)})
+		}
+	}
+	switch len(nodes) {
+	case 0:
+		return nil, nil
+	case 1:
+		return nodes[0], nil
+	}
+	return nodes, nil
 }
@@ -0,0 +1,73 @@
+package api
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"go1f/pkg/db"
+
+	_ "time/tzdata" // База часовых поясов на случай её отсутствия в системе.
+)
+
+// TimeString содержит строковый формат представления времени выполнения задачи.
+var TimeString = "15:04"
+
+var envTZ = os.Getenv("TODO_TZ") // Получаем переменную окружения TODO_TZ.
+
+// defaultLocation содержит часовой пояс сервера по умолчанию: из переменной TODO_TZ или локальный.
+var defaultLocation = time.Local
+
+// loadLocation устанавливает часовой пояс по умолчанию из переменной среды окружения TODO_TZ, если она задана.
+func loadLocation() error {
+	if len(envTZ) == 0 {
+		return nil
+	}
+	loc, err := time.LoadLocation(envTZ)
+	if err != nil {
+		return fmt.Errorf("недопустимый часовой пояс TODO_TZ '%s': %w", envTZ, err)
+	}
+	defaultLocation = loc
+	return nil
+}
+
+// location возвращает часовой пояс по его имени IANA tz ("Europe/Moscow"). Для пустого имени
+// возвращает часовой пояс по умолчанию.
+func location(tz string) (*time.Location, error) {
+	if len(tz) == 0 {
+		return defaultLocation, nil
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return nil, fmt.Errorf("недопустимый часовой пояс '%s'", tz)
+	}
+	return loc, nil
+}
+
+// today возвращает текущую дату в часовом поясе tz (пустая строка - часовой пояс по умолчанию).
+// Дата возвращается как полночь UTC, так же как и даты задач, разобранные в формате "20060102".
+func today(tz string) (time.Time, error) {
+	loc, err := location(tz)
+	if err != nil {
+		return time.Time{}, err
+	}
+	now := time.Now().In(loc)
+	return time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC), nil
+}
+
+// checkTime проверяет на корректность время выполнения и часовой пояс задачи task. Время приводится
+// к формату "15:04".
+func checkTime(task *db.Task) error {
+	if _, err := location(task.TZ); err != nil {
+		return err
+	}
+	if len(task.Time) == 0 {
+		return nil
+	}
+	t, err := time.Parse(TimeString, task.Time)
+	if err != nil {
+		return fmt.Errorf("недопустимое время задачи '%s' (ожидается ЧЧ:ММ)", task.Time)
+	}
+	task.Time = t.Format(TimeString)
+	return nil
+}
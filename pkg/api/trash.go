@@ -0,0 +1,46 @@
+package api
+
+import (
+	"net/http"
+
+	"go1f/pkg/db"
+)
+
+// trashHandler распределяет обращение по адресу в соответствии с методом запроса.
+func trashHandler(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case r.Method == http.MethodGet:
+		listTasks(w, r, true)
+	case r.Method == http.MethodDelete:
+		purgeTaskHandler(w, r)
+	default:
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+	}
+}
+
+// purgeTaskHandler обрабатывает DELETE-запрос по переданному в URL "id" на окончательное удаление задачи
+// из корзины вместе с её исключёнными датами. В случае успешного выполнения возвращает пустой json.
+// В случае неудачи возвращает ошибку в json-формате.
+func purgeTaskHandler(w http.ResponseWriter, r *http.Request) {
+	err := db.PurgeTask(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, map[string]interface{}{})
+}
+
+// restoreHandler обрабатывает POST-запрос по переданному в URL "id" на возврат задачи из корзины.
+// В случае успешного выполнения возвращает пустой json. В случае неудачи возвращает ошибку в json-формате.
+func restoreHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "метод не поддерживается", http.StatusMethodNotAllowed)
+		return
+	}
+	err := db.RestoreTask(r.Context(), r.URL.Query().Get("id"))
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	writeJson(w, map[string]interface{}{})
+}
@@ -10,38 +10,68 @@ import (
 )
 
 // updateTaskHandler обрабатывает PUT-запрос, в теле которого передан экземпляр структуры задачи в
-// json-формате, на обновление полей таблицы базы данных, соответствующих "id".
-// В случае успеха возвращает пустой json, в случае неудачи - ошибку в json-формате.
+// json-формате, на обновление полей таблицы базы данных, соответствующих "id". Поля, не переданные
+// в запросе, не изменяются.
+// Задача обновляется, только если её версия не изменилась с момента чтения: ожидаемая версия берётся
+// из заголовка If-Match (ETag из getTaskHandler), поля "version" тела запроса или, если они не переданы,
+// из прочитанной обработчиком задачи. При несоответствии If-Match возвращает 412, при изменении задачи
+// другим запросом - 409 (412, если передан If-Match).
+// В случае успеха возвращает пустой json и новую версию задачи в заголовке ETag, в случае неудачи -
+// ошибку в json-формате.
 func updateTaskHandler(w http.ResponseWriter, r *http.Request) {
 	var task db.Task
 	var buf bytes.Buffer
 	_, err := buf.ReadFrom(r.Body)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
 	if err = json.Unmarshal(buf.Bytes(), &task); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
+	// Поля, отсутствующие в запросе, сохраняют текущие значения задачи.
+	stored, err := db.GetTask(r.Context(), task.ID)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	given, ok := ifMatch(r, stored.Version)
+	if !ok {
+		writePreconditionErr(w)
+		return
+	}
+	task = *stored
+	if err = json.Unmarshal(buf.Bytes(), &task); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
+		return
+	}
+	if given {
+		task.Version = stored.Version
+	}
 	if task.Title == "" {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, fmt.Errorf("не указан заголовок задачи"))
+		writeErr(w, fmt.Errorf("не указан заголовок задачи"), http.StatusBadRequest)
+		return
+	}
+	except, err := taskExclusions(r.Context(), &task)
+	if err != nil {
+		writeDbErr(w, err)
+		return
+	}
+	if err = checkDate(&task, except); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
-	if err = checkDate(&task); err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+	if err = checkSeries(&task); err != nil {
+		writeErr(w, err, http.StatusBadRequest)
 		return
 	}
-	err = db.UpdateTask(&task)
+	err = db.UpdateTask(r.Context(), &task)
 	if err != nil {
-		w.WriteHeader(http.StatusBadRequest)
-		writeJsonErr(w, err)
+		writeVersionErr(w, r, err)
 		return
 	}
+	w.Header().Set("ETag", etag(task.Version))
 	w.WriteHeader(http.StatusOK)
 	writeJson(w, map[string]interface{}{})
 }
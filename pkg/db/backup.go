@@ -0,0 +1,219 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// backupLayout содержит формат времени в именах файлов резервных копий вида "scheduler-20240102-150405.db".
+const backupLayout = "20060102-150405"
+
+// Backuper реализуется хранилищами, из базы данных которых можно получить согласованную резервную копию
+// без остановки приложения.
+type Backuper interface {
+	Backup(ctx context.Context, path string) error
+}
+
+// Backup записывает в новый файл path согласованный снимок базы данных SQLite командой VACUUM INTO.
+// Снимок создаётся в одной транзакции чтения, поэтому не содержит незавершённых изменений, и не мешает
+// работе других соединений. Если файл path уже существует, возвращает ошибку.
+func (s *SQLiteStore) Backup(ctx context.Context, path string) error {
+	if _, err := os.Stat(path); err == nil {
+		return fmt.Errorf("файл '%s' уже существует", path)
+	}
+	_, err := s.db.ExecContext(ctx, `VACUUM INTO ?`, path)
+	return err
+}
+
+// Backup записывает в новый файл path резервную копию базы данных хранилища. Копирование ограничено
+// сроком BackupTimeout. Для хранилища без резервного копирования возвращает ошибку.
+func Backup(ctx context.Context, path string) error {
+	b, ok := store.(Backuper)
+	if !ok {
+		return fmt.Errorf("хранилище не поддерживает резервное копирование")
+	}
+	ctx, cancel := context.WithTimeout(ctx, BackupTimeout)
+	defer cancel()
+	return storeErr(ctx, b.Backup(ctx, path))
+}
+
+// BackupToDir записывает резервную копию базы данных хранилища в каталог dir под именем с текущим временем
+// и удаляет самые старые резервные копии каталога сверх keep (0 - копии не удаляются). Возвращает путь
+// к созданной копии и возможную ошибку.
+func BackupToDir(ctx context.Context, dir string, keep int) (string, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return "", err
+	}
+	path := filepath.Join(dir, "scheduler-"+time.Now().UTC().Format(backupLayout)+".db")
+	if err := Backup(ctx, path); err != nil {
+		return "", err
+	}
+	return path, rotateBackups(dir, keep)
+}
+
+// rotateBackups удаляет из каталога dir самые старые резервные копии, оставляя keep последних.
+// Остальные файлы каталога не затрагиваются.
+func rotateBackups(dir string, keep int) error {
+	if keep <= 0 {
+		return nil
+	}
+	files, err := filepath.Glob(filepath.Join(dir, "scheduler-*.db"))
+	if err != nil {
+		return err
+	}
+	var backups []string
+	for _, file := range files {
+		stamp := strings.TrimSuffix(strings.TrimPrefix(filepath.Base(file), "scheduler-"), ".db")
+		if _, err := time.Parse(backupLayout, stamp); err == nil {
+			backups = append(backups, file)
+		}
+	}
+	// Время в имени записано с ведущими нулями, поэтому строковый порядок совпадает с порядком времени.
+	sort.Strings(backups)
+	for len(backups) > keep {
+		if err := os.Remove(backups[0]); err != nil {
+			return err
+		}
+		backups = backups[1:]
+	}
+	return nil
+}
+
+// CheckSnapshot проверяет, что файл path является неповреждённой базой данных SQLite приложения:
+// проходит проверку целостности, содержит таблицу scheduler, а версия схемы не новее приложения.
+// Снимок более старой версии, в том числе созданный до появления миграций, допустим - недостающие
+// миграции применяются при запуске сервера. Возвращает версию схемы снимка и возможную ошибку.
+func CheckSnapshot(path string) (int, error) {
+	// Файл проверяется до открытия: иначе драйвер создаст пустую базу данных.
+	if _, err := os.Stat(path); err != nil {
+		return 0, err
+	}
+	s, err := NewSQLiteStore(path)
+	if err != nil {
+		return 0, err
+	}
+	defer s.Close()
+
+	var result string
+	if err := s.db.Get(&result, `PRAGMA integrity_check`); err != nil {
+		return 0, fmt.Errorf("файл '%s' не является базой данных SQLite: %w", path, err)
+	}
+	if result != "ok" {
+		return 0, fmt.Errorf("база данных '%s' повреждена: %s", path, result)
+	}
+	var count int
+	if err := s.db.Get(&count, s.tableExists, "scheduler"); err != nil {
+		return 0, err
+	}
+	if count == 0 {
+		return 0, fmt.Errorf("база данных '%s' не содержит таблицы scheduler", path)
+	}
+	status, err := s.Status()
+	if err != nil {
+		return 0, err
+	}
+	if status.Current > status.Latest {
+		return 0, fmt.Errorf("%w: версия %d, приложение поддерживает до %d", ErrSchemaTooNew,
+			status.Current, status.Latest)
+	}
+	return status.Current, nil
+}
+
+// RestoreSnapshot проверяет снимок src функцией CheckSnapshot и заменяет им файл базы данных SQLite
+// хранилища. Сервер на время восстановления должен быть остановлен. Возвращает версию схемы снимка
+// и возможную ошибку.
+func RestoreSnapshot(src string) (int, error) {
+	dst := getDSN()
+	if dst == MemoryDSN || strings.HasPrefix(dst, "postgres://") || strings.HasPrefix(dst, "postgresql://") {
+		return 0, fmt.Errorf("восстановление поддерживается только для базы данных SQLite")
+	}
+	version, err := CheckSnapshot(src)
+	if err != nil {
+		return 0, err
+	}
+	if err := restoreFile(src, dst); err != nil {
+		return 0, err
+	}
+	return version, nil
+}
+
+// restoreFile заменяет файл базы данных SQLite dst копией файла src. Снимок сначала копируется во
+// временный файл рядом с базой данных, который затем переименовывается, поэтому при ошибке прежняя база
+// данных остаётся нетронутой. На время замены база данных блокируется функцией lockSQLite, поэтому
+// замена не прерывает транзакцию работающего сервера, а возвращает ErrBusy. Замена отклоняется и при
+// оставшихся рядом файлах журнала: SQLite применил бы их к восстановленной базе данных и повредил её.
+func restoreFile(src, dst string) error {
+	tmp := dst + ".restore"
+	defer os.Remove(tmp)
+	if err := copyFile(src, tmp); err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(dst); err == nil {
+		unlock, err := lockSQLite(dst)
+		if err != nil {
+			return err
+		}
+		defer unlock()
+	}
+	for _, suffix := range []string{"-journal", "-wal", "-shm"} {
+		if _, err := os.Stat(dst + suffix); err == nil {
+			return fmt.Errorf("рядом с базой данных '%s' найден файл журнала '%s': остановите сервер и "+
+				"удалите его вручную", dst, dst+suffix)
+		}
+	}
+	return os.Rename(tmp, dst)
+}
+
+// lockSQLite открывает базу данных SQLite в файле path и начинает транзакцию BEGIN EXCLUSIVE без ожидания
+// блокировки: до вызова возвращаемой функции unlock другие соединения не могут ни читать, ни изменять базу
+// данных. Если другое соединение в этот момент выполняет транзакцию, возвращает ErrBusy.
+func lockSQLite(path string) (unlock func(), err error) {
+	conn, err := sqlx.Open("sqlite", path+"?_pragma=busy_timeout(0)&_txlock=exclusive")
+	if err != nil {
+		return nil, err
+	}
+	tx, err := conn.Begin()
+	if err != nil {
+		conn.Close()
+		if sqliteBusy(err) {
+			return nil, fmt.Errorf("%w: база данных '%s' используется, остановите сервер перед восстановлением",
+				ErrBusy, path)
+		}
+		return nil, err
+	}
+	return func() {
+		tx.Rollback()
+		conn.Close()
+	}, nil
+}
+
+// copyFile копирует файл src в файл dst, сбрасывая содержимое dst на диск.
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	if err := out.Sync(); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
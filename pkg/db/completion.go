@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Completion соответствует полям таблицы task_completions - записи о выполнении задачи с идентификатором
+// TaskID. Title содержит заголовок задачи на момент выполнения, Date - дату, на которую задача была
+// назначена, DoneAt - время выполнения в UTC в формате RFC 3339, Note - необязательную заметку.
+// Записи сохраняются и после окончательного удаления задачи.
+type Completion struct {
+	ID     string `json:"id" db:"id"`
+	TaskID string `json:"task_id" db:"task_id"`
+	Title  string `json:"title" db:"title"`
+	Date   string `json:"date" db:"date"`
+	DoneAt string `json:"done_at" db:"done_at"`
+	Note   string `json:"note,omitempty" db:"note"`
+}
+
+// doneAt возвращает время выполнения c.DoneAt, если оно задано, иначе текущее время.
+func doneAt(c *Completion) string {
+	if len(c.DoneAt) > 0 {
+		return c.DoneAt
+	}
+	return timestamp(time.Now())
+}
+
+// CompleteTask в одной транзакции добавляет в таблицу task_completions запись о выполнении c и либо
+// обновляет задачу полями next (перенос на следующую дату), либо, если next равно nil, перемещает
+// задачу с идентификатором c.TaskID в корзину. Ненулевая version - ожидаемая версия задачи: если задача
+// уже изменена, возвращается ErrConflict (см. UpdateTask). Если задача не найдена или изменена, запись
+// не добавляется. Возвращает возможную ошибку.
+func (s *SQLiteStore) CompleteTask(ctx context.Context, c *Completion, version int64, next *Task) error {
+	query := `INSERT INTO task_completions (task_id, title, date, done_at, note)
+	VALUES (:task_id, :title, :date, :done_at, :note)`
+
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, query,
+			sql.Named("task_id", c.TaskID),
+			sql.Named("title", c.Title),
+			sql.Named("date", c.Date),
+			sql.Named("done_at", doneAt(c)),
+			sql.Named("note", c.Note))
+		if err != nil {
+			return err
+		}
+		if next != nil {
+			next.Version = version
+			return updateTask(ctx, tx, next)
+		}
+		return deleteTask(ctx, tx, c.TaskID, version)
+	})
+}
+
+// TaskCompletions возвращает записи о выполнении задачи с идентификатором id, упорядоченные по времени
+// выполнения, и возможную ошибку.
+func (s *SQLiteStore) TaskCompletions(ctx context.Context, id string) ([]Completion, error) {
+	list := make([]Completion, 0)
+	query := `SELECT id, task_id, title, date, done_at, note FROM task_completions
+	WHERE task_id = :id ORDER BY done_at, id`
+
+	err := s.db.SelectContext(ctx, &list, query, sql.Named("id", id))
+	return list, err
+}
+
+// Completions возвращает записи о выполнении всех задач со временем выполнения не раньше from и раньше
+// to, упорядоченные по времени выполнения, и возможную ошибку. Нулевое from или to не ограничивает выборку.
+func (s *SQLiteStore) Completions(ctx context.Context, from, to time.Time) ([]Completion, error) {
+	list := make([]Completion, 0)
+	query := `SELECT id, task_id, title, date, done_at, note FROM task_completions
+	WHERE done_at >= :from AND (:to = '' OR done_at < :to) ORDER BY done_at, id`
+
+	err := s.db.SelectContext(ctx, &list, query, sql.Named("from", rangeBound(from)), sql.Named("to", rangeBound(to)))
+	return list, err
+}
+
+// rangeBound возвращает границу выборки по времени t в формате timestamp или пустую строку для
+// нулевого t.
+func rangeBound(t time.Time) string {
+	if t.IsZero() {
+		return ""
+	}
+	return timestamp(t)
+}
@@ -2,35 +2,24 @@
 package db
 
 import (
+	"context"
 	"os"
-
-	"github.com/jmoiron/sqlx"
-
-	_ "modernc.org/sqlite"
 )
 
-// Schema содержит команду инициализации таблицы scheduler.
-const Schema = `
-CREATE TABLE scheduler (
-    id INTEGER PRIMARY KEY AUTOINCREMENT,
-    date CHAR(8) NOT NULL DEFAULT "",
-    title VARCHAR(256) NOT NULL DEFAULT "",
-    comment TEXT NOT NULL DEFAULT "",
-	repeat VARCHAR(128) NOT NULL DEFAULT ""
-);
-CREATE INDEX scheduler_date ON scheduler (date);
-`
-
 // DefaultDbFile содержит путь по умолчанию к базе данных scheduler.db.
 var DefaultDbFile = "scheduler.db"
 
 // DateString содержит строковый формат представления даты
 var DateString = "20060102"
 
-var db *sqlx.DB // инициализация обработчика базы данных.
-
 var envDbFile = os.Getenv("TODO_DBFILE") // Получаем переменную окружения TODO_DBFILE.
 
+var envHolidaysFile = os.Getenv("TODO_HOLIDAYS") // Получаем переменную окружения TODO_HOLIDAYS.
+
+var envDSN = os.Getenv("TODO_DSN") // Получаем переменную окружения TODO_DSN.
+
+var envTimeout = os.Getenv("TODO_DB_TIMEOUT") // Получаем переменную окружения TODO_DB_TIMEOUT.
+
 // getDbFile возвращает путь к файлу базы данных scheduler.db.
 // Если нет переменной среды окружения TODO_DBFILE с актуальным адресом, возвращает значение по умолчанию defaultDbFile.
 func getDbFile() string {
@@ -41,33 +30,45 @@ func getDbFile() string {
 	return dbFile
 }
 
-// Init проверяет наличие файла базы данных scheduler.db по актуальному пути.
-// Если файл отсутствует, создает его и таблицу scheduler в нём. Устанавливает
-// соединение db с этой БД.
-func Init() error {
-	dbFile := getDbFile()
-	_, err := os.Stat(dbFile)
-
-	var install bool
-	if err != nil {
-		install = true
+// getDSN возвращает строку подключения к хранилищу задач из переменной среды окружения TODO_DSN
+// (см. NewStore). Если она отсутствует, возвращает путь к файлу базы данных SQLite из getDbFile.
+func getDSN() string {
+	if len(envDSN) > 0 {
+		return envDSN
 	}
+	return getDbFile()
+}
 
-	db, err = sqlx.Open("sqlite", dbFile)
+// Open открывает хранилище задач по актуальной строке подключения, не изменяя схему его базы данных.
+func Open() error {
+	s, err := NewStore(getDSN())
 	if err != nil {
 		return err
 	}
+	store = s
+	return nil
+}
 
-	if install {
-		_, err := db.Exec(Schema)
-		if err != nil {
+// Init открывает хранилище задач по актуальной строке подключения и применяет к его базе данных
+// недостающие миграции схемы. Если схема базы данных новее приложения, возвращает ErrSchemaTooNew.
+// Если задана переменная среды окружения TODO_HOLIDAYS, загружает из указанного в ней файла праздничные дни;
+// файл читается только здесь, поэтому после его изменения сервер нужно перезапустить.
+func Init() error {
+	if err := Open(); err != nil {
+		return err
+	}
+	if _, err := Migrate(false); err != nil {
+		return err
+	}
+	if len(envHolidaysFile) > 0 {
+		if _, err := ImportHolidays(context.Background(), envHolidaysFile); err != nil {
 			return err
 		}
 	}
 	return nil
 }
 
-// Close закрывает соединение db с базой данных scheduler.db
+// Close закрывает хранилище задач.
 func Close() {
-	db.Close()
+	store.Close()
 }
@@ -0,0 +1,94 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Exclusions возвращает упорядоченный список исключённых дат задачи с идентификатором id
+// из таблицы scheduler_exclusions и возможную ошибку.
+func (s *SQLiteStore) Exclusions(ctx context.Context, id string) ([]string, error) {
+	dates := make([]string, 0)
+	query := `SELECT date FROM scheduler_exclusions WHERE task_id = :id ORDER BY date`
+
+	rows, err := s.db.QueryContext(ctx, query, sql.Named("id", id))
+	if err != nil {
+		return dates, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var date string
+		if err := rows.Scan(&date); err != nil {
+			return dates, err
+		}
+		dates = append(dates, date)
+	}
+	return dates, rows.Err()
+}
+
+// AddExclusion в одной транзакции добавляет дату date в список исключённых дат задачи с идентификатором id
+// и, если next не равен nil, обновляет задачу полями next (перенос с исключённой даты). Ненулевая version -
+// ожидаемая версия задачи: если задача уже изменена, возвращается ErrConflict (см. UpdateTask), и дата
+// не добавляется. Повторное добавление той же даты не является ошибкой. Возвращает возможную ошибку.
+func (s *SQLiteStore) AddExclusion(ctx context.Context, id, date string, version int64, next *Task) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	query := `INSERT INTO scheduler_exclusions (task_id, date) VALUES (:id, :date) ON CONFLICT DO NOTHING`
+
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		if next != nil {
+			next.Version = version
+			if err := updateTask(ctx, tx, next); err != nil {
+				return err
+			}
+		} else if err := checkTaskVersion(ctx, tx, id, version); err != nil {
+			return err
+		}
+		_, err := tx.ExecContext(ctx, query, sql.Named("id", id), sql.Named("date", date))
+		return err
+	})
+}
+
+// checkTaskVersion проверяет в транзакции tx, что действующая задача с идентификатором id имеет версию
+// version. Нулевая version не проверяется.
+func checkTaskVersion(ctx context.Context, tx *sqlx.Tx, id string, version int64) error {
+	if version == 0 {
+		return nil
+	}
+	var current int64
+	query := `SELECT version FROM scheduler WHERE id = :id AND deleted_at = ''`
+	err := tx.GetContext(ctx, &current, query, sql.Named("id", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return errInvalid("задача не найдена")
+	}
+	if err != nil {
+		return err
+	}
+	if current != version {
+		return ErrConflict
+	}
+	return nil
+}
+
+// DeleteExclusion удаляет дату date из списка исключённых дат задачи с идентификатором id.
+// Возвращает возможную ошибку.
+func (s *SQLiteStore) DeleteExclusion(ctx context.Context, id, date string) error {
+	query := `DELETE FROM scheduler_exclusions WHERE task_id = :id AND date = :date`
+
+	res, err := s.db.ExecContext(ctx, query, sql.Named("id", id), sql.Named("date", date))
+	if err != nil {
+		return err
+	}
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errInvalid("исключённая дата не найдена")
+	}
+	return nil
+}
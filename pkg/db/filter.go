@@ -0,0 +1,169 @@
+package db
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"go1f/pkg/filter"
+)
+
+// textCondition возвращает условие SQL с параметрами "?" на слова заголовка и/или комментария задачи
+// для условия запроса term. Зависит от СУБД.
+type textCondition func(term filter.Term) (string, []any)
+
+// compileFilter компилирует дерево условий запроса node в условие WHERE с параметрами "?" для таблицы
+// scheduler с псевдонимом s. Значения условий передаются только параметрами. Для пустого дерева
+// возвращает пустую строку.
+func compileFilter(node filter.Node, text textCondition) (string, []any) {
+	if node == nil {
+		return "", nil
+	}
+	var args []any
+	var compile func(node filter.Node) string
+	compile = func(node filter.Node) string {
+		switch n := node.(type) {
+		case filter.And:
+			return "(" + compileAll(n, " AND ", compile) + ")"
+		case filter.Or:
+			return "(" + compileAll(n, " OR ", compile) + ")"
+		case filter.Not:
+			return "NOT " + compile(n.Node)
+		case filter.Term:
+			cond, termArgs := compileTerm(n, text)
+			args = append(args, termArgs...)
+			return cond
+		}
+		panic(fmt.Sprintf("неизвестный узел запроса %T", node))
+	}
+	return compile(node), args
+}
+
+// compileAll компилирует условия nodes функцией compile и объединяет их через sep.
+func compileAll[T ~[]filter.Node](nodes T, sep string, compile func(filter.Node) string) string {
+	var parts []string
+	for _, node := range nodes {
+		parts = append(parts, compile(node))
+	}
+	return strings.Join(parts, sep)
+}
+
+// compileTerm компилирует отдельное условие запроса term.
+func compileTerm(term filter.Term, text textCondition) (string, []any) {
+	switch term.Field {
+	case filter.FieldRepeat:
+		if term.Value == filter.AnyRepeat {
+			return `s.repeat <> ''`, nil
+		}
+		return `(s.repeat <> '' AND lower(s.repeat) LIKE ? ESCAPE '\')`, []any{likePrefix(term.Value)}
+	case filter.FieldDate:
+		return `s.date = ?`, []any{term.Value}
+	case filter.FieldBefore:
+		return `s.date < ?`, []any{term.Value}
+	case filter.FieldAfter:
+		return `s.date > ?`, []any{term.Value}
+	}
+	return text(term)
+}
+
+// likePrefix возвращает шаблон LIKE, которому соответствуют строки, начинающиеся с s без учёта регистра.
+func likePrefix(s string) string {
+	return likeEscape(strings.ToLower(s)) + "%"
+}
+
+// likeEscape экранирует в s символы шаблона LIKE обратной косой чертой.
+func likeEscape(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// ftsTerm возвращает выражение FTS5 для условия запроса на слова term: для полей title и comment
+// поиск ограничивается соответствующим столбцом.
+func ftsTerm(term filter.Term) string {
+	expr := ftsQuery(term.Value)
+	if term.Field == filter.FieldText {
+		return "(" + expr + ")"
+	}
+	return string(term.Field) + " : (" + expr + ")"
+}
+
+// sqliteText - условие на слова для SQLite: поиск в полнотекстовом индексе scheduler_fts.
+func sqliteText(term filter.Term) (string, []any) {
+	return `s.id IN (SELECT rowid FROM scheduler_fts WHERE scheduler_fts MATCH ?)`, []any{ftsTerm(term)}
+}
+
+// postgresText - условие на слова для PostgreSQL: каждое слово ищется как подстрока без учёта регистра.
+func postgresText(term filter.Term) (string, []any) {
+	var conds []string
+	var args []any
+	for _, word := range searchTerms(term.Value) {
+		pattern := "%" + likeEscape(word) + "%"
+		switch term.Field {
+		case filter.FieldTitle:
+			conds = append(conds, `s.title ILIKE ?`)
+			args = append(args, pattern)
+		case filter.FieldComment:
+			conds = append(conds, `s.comment ILIKE ?`)
+			args = append(args, pattern)
+		default:
+			conds = append(conds, `(s.title ILIKE ? OR s.comment ILIKE ?)`)
+			args = append(args, pattern, pattern)
+		}
+	}
+	return "(" + strings.Join(conds, " AND ") + ")", args
+}
+
+// matchFilter проверяет соответствие задачи task дереву условий запроса node так же, как условия,
+// скомпилированные compileFilter с поиском в полнотекстовом индексе.
+func matchFilter(node filter.Node, task *Task) bool {
+	switch n := node.(type) {
+	case nil:
+		return true
+	case filter.And:
+		for _, c := range n {
+			if !matchFilter(c, task) {
+				return false
+			}
+		}
+		return true
+	case filter.Or:
+		for _, c := range n {
+			if matchFilter(c, task) {
+				return true
+			}
+		}
+		return false
+	case filter.Not:
+		return !matchFilter(n.Node, task)
+	case filter.Term:
+		switch n.Field {
+		case filter.FieldRepeat:
+			return task.Repeat != "" && (n.Value == filter.AnyRepeat ||
+				strings.HasPrefix(strings.ToLower(task.Repeat), strings.ToLower(n.Value)))
+		case filter.FieldDate:
+			return task.Date == n.Value
+		case filter.FieldBefore:
+			return task.Date < n.Value
+		case filter.FieldAfter:
+			return task.Date > n.Value
+		case filter.FieldTitle:
+			return matchWords(n.Value, task.Title)
+		case filter.FieldComment:
+			return matchWords(n.Value, task.Comment)
+		}
+		return matchWords(n.Value, task.Title+" "+task.Comment)
+	}
+	return false
+}
+
+// matchWords проверяет, что каждое слово строки search является началом какого-либо слова текста text
+// без учёта регистра.
+func matchWords(search, text string) bool {
+	words := searchTerms(strings.ToLower(text))
+	terms := searchTerms(strings.ToLower(search))
+	for _, term := range terms {
+		if !slices.ContainsFunc(words, func(w string) bool { return strings.HasPrefix(w, term) }) {
+			return false
+		}
+	}
+	return len(terms) > 0
+}
@@ -0,0 +1,179 @@
+package db
+
+import (
+	"bufio"
+	"context"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Holiday соответствует полям таблицы holidays базы данных scheduler.db.
+type Holiday struct {
+	Date  string `json:"date"`
+	Title string `json:"title"`
+}
+
+// holidayDateFormats содержит допустимые форматы дат в файле праздничных дней.
+var holidayDateFormats = []string{DateString, "2006-01-02", "02.01.2006"}
+
+// Holidays возвращает список праздничных дней из таблицы holidays, упорядоченный по дате,
+// и возможную ошибку.
+func (s *SQLiteStore) Holidays(ctx context.Context) ([]Holiday, error) {
+	var list []Holiday
+	rows, err := s.db.QueryContext(ctx, `SELECT date, title FROM holidays ORDER BY date`)
+	if err != nil {
+		return list, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h Holiday
+		if err := rows.Scan(&h.Date, &h.Title); err != nil {
+			return list, err
+		}
+		list = append(list, h)
+	}
+	return list, rows.Err()
+}
+
+// AddHolidays добавляет праздничные дни из list в таблицу holidays. Если дата уже присутствует
+// в таблице, обновляет её название. Возвращает возможную ошибку.
+func (s *SQLiteStore) AddHolidays(ctx context.Context, list []Holiday) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, h := range list {
+		_, err := tx.ExecContext(ctx, `INSERT INTO holidays (date, title) VALUES (?, ?)
+		ON CONFLICT (date) DO UPDATE SET title = excluded.title`, h.Date, h.Title)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// ImportHolidays загружает праздничные дни из файла календаря path в формате ICS (расширение ".ics")
+// или CSV (строки вида "дата,название") в таблицу holidays. Возвращает количество загруженных дней
+// и возможную ошибку.
+func ImportHolidays(ctx context.Context, path string) (int, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer f.Close()
+	var list []Holiday
+	if strings.EqualFold(filepath.Ext(path), ".ics") {
+		list, err = parseHolidaysICS(f)
+	} else {
+		list, err = parseHolidaysCSV(f)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("файл календаря %s: %w", path, err)
+	}
+	return len(list), AddHolidays(ctx, list)
+}
+
+// parseHolidayDate конвертирует строку s с датой в одном из форматов holidayDateFormats в формат DateString.
+func parseHolidayDate(s string) (time.Time, error) {
+	s = strings.TrimSpace(s)
+	for _, layout := range holidayDateFormats {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("недопустимая дата: '%s'", s)
+}
+
+// parseHolidaysCSV читает праздничные дни из r в формате CSV. Первый столбец содержит дату, второй
+// (необязательный) - название. Строка заголовка, если первый столбец не является датой, пропускается.
+func parseHolidaysCSV(r io.Reader) ([]Holiday, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+	var list []Holiday
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if err == io.EOF {
+			return list, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		date, err := parseHolidayDate(record[0])
+		if err != nil {
+			if line == 1 {
+				continue
+			}
+			return nil, fmt.Errorf("строка %d: %w", line, err)
+		}
+		h := Holiday{Date: date.Format(DateString)}
+		if len(record) > 1 {
+			h.Title = record[1]
+		}
+		list = append(list, h)
+	}
+}
+
+// parseHolidaysICS читает праздничные дни из r в формате ICS. Каждое событие VEVENT даёт праздничные дни
+// от DTSTART включительно до DTEND исключительно (или один день, если DTEND отсутствует).
+func parseHolidaysICS(r io.Reader) ([]Holiday, error) {
+	var lines []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimRight(scanner.Text(), "\r")
+		// Строки, начинающиеся с пробела или табуляции, продолжают предыдущую строку.
+		if len(lines) > 0 && (strings.HasPrefix(line, " ") || strings.HasPrefix(line, "\t")) {
+			lines[len(lines)-1] += line[1:]
+			continue
+		}
+		lines = append(lines, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	var list []Holiday
+	var inEvent bool
+	var start, end time.Time
+	var title string
+	for _, line := range lines {
+		name, value, _ := strings.Cut(line, ":")
+		name, _, _ = strings.Cut(name, ";")
+		var err error
+		switch strings.ToUpper(name) {
+		case "BEGIN":
+			if strings.EqualFold(value, "VEVENT") {
+				inEvent, start, end, title = true, time.Time{}, time.Time{}, ""
+			}
+		case "DTSTART":
+			start, err = parseHolidayDate(value[:min(len(value), len(DateString))])
+		case "DTEND":
+			end, err = parseHolidayDate(value[:min(len(value), len(DateString))])
+		case "SUMMARY":
+			title = strings.ReplaceAll(value, `\,`, ",")
+		case "END":
+			if !inEvent || !strings.EqualFold(value, "VEVENT") {
+				continue
+			}
+			inEvent = false
+			if start.IsZero() {
+				return nil, fmt.Errorf("событие '%s' без DTSTART", title)
+			}
+			if !end.After(start) {
+				end = start.AddDate(0, 0, 1)
+			}
+			for d := start; d.Before(end); d = d.AddDate(0, 0, 1) {
+				list = append(list, Holiday{Date: d.Format(DateString), Title: title})
+			}
+		}
+		if err != nil && inEvent {
+			return nil, err
+		}
+	}
+	return list, nil
+}
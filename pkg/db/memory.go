@@ -0,0 +1,436 @@
+package db
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+)
+
+// MemoryStore - хранилище задач в оперативной памяти для тестов и демонстрации. Содержимое хранилища
+// теряется при завершении приложения. Контекст операций не используется: они выполняются без ожидания
+// ввода-вывода.
+type MemoryStore struct {
+	mu          sync.Mutex
+	lastID      int64
+	tasks       map[string]Task
+	exclusions  map[string]map[string]bool
+	holidays    map[string]string
+	completions []Completion
+	revisions   []Revision
+}
+
+// NewMemoryStore возвращает пустое хранилище задач в оперативной памяти.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tasks:      make(map[string]Task),
+		exclusions: make(map[string]map[string]bool),
+		holidays:   make(map[string]string),
+	}
+}
+
+// AddTask добавляет в хранилище копию задачи task с новым id. Возвращает id добавленной задачи.
+func (s *MemoryStore) AddTask(ctx context.Context, task *Task) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.lastID++
+	stored := *task
+	stored.ID = strconv.FormatInt(s.lastID, 10)
+	stored.CreatedAt = createdAt(task)
+	stored.DeletedAt = ""
+	stored.RepeatText = ""
+	stored.Version = 1
+	s.tasks[stored.ID] = stored
+	s.addRevision(stored.ID, RevisionCreate, nil, &stored)
+	return s.lastID, nil
+}
+
+// Tasks возвращает страницу задач, соответствующих дереву условий запроса q.Filter (nil - все задачи),
+// в порядке q.Sort (по умолчанию по дате, времени и id). Условия проверяются так же, как
+// в SQLiteStore.Tasks, но без упорядочивания по релевантности и без фрагментов текста.
+func (s *MemoryStore) Tasks(ctx context.Context, q TaskQuery) (*TaskPage, error) {
+	if err := q.validate(); err != nil {
+		return &TaskPage{Tasks: make([]*Task, 0)}, err
+	}
+	keys := sortKeys(q.Sort)
+	order := q.Sort
+	if len(order) == 0 {
+		order = SortDate
+	}
+	var after []any
+	if len(q.Cursor) > 0 {
+		var err error
+		if after, err = decodeCursor(q.Cursor, order, len(keys)); err != nil {
+			return &TaskPage{Tasks: make([]*Task, 0)}, err
+		}
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	page := &TaskPage{Tasks: make([]*Task, 0)}
+	var tasks []*Task
+	for _, task := range s.tasks {
+		if (len(task.DeletedAt) > 0) == q.Trash && matchFilter(q.Filter, &task) {
+			tasks = append(tasks, &task)
+		}
+	}
+	page.Total = len(tasks)
+	sort.Slice(tasks, func(i, j int) bool {
+		return compareKeys(keys, sortValues(tasks[i], order), sortValues(tasks[j], order)) < 0
+	})
+	for _, task := range tasks {
+		if after != nil && compareKeys(keys, sortValues(task, order), after) <= 0 {
+			continue
+		}
+		if len(page.Tasks) == q.Limit {
+			page.NextCursor = encodeCursor(order, sortValues(page.Tasks[q.Limit-1], order))
+			break
+		}
+		page.Tasks = append(page.Tasks, task)
+	}
+	return page, nil
+}
+
+// GetTask возвращает копию задачи с идентификатором id, не находящейся в корзине.
+func (s *MemoryStore) GetTask(ctx context.Context, id string) (*Task, error) {
+	if id == "" {
+		return &Task{}, errInvalid("не указан идентификатор")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok || len(task.DeletedAt) > 0 {
+		return &Task{}, errInvalid("задача не найдена")
+	}
+	return &task, nil
+}
+
+// UpdateTask заменяет задачу с идентификатором task.ID, не находящуюся в корзине, копией task,
+// сохраняя время создания задачи. Ненулевая task.Version проверяется как в SQLiteStore.UpdateTask.
+func (s *MemoryStore) UpdateTask(ctx context.Context, task *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.updateTask(task)
+}
+
+// updateTask заменяет задачу копией task и записывает изменение в историю. Вызывается при заблокированном
+// хранилище.
+func (s *MemoryStore) updateTask(task *Task) error {
+	return s.revise(task.ID, RevisionUpdate, func() error {
+		old, ok := s.tasks[task.ID]
+		if !ok || len(old.DeletedAt) > 0 {
+			return errInvalid("неверный id для обновления задачи")
+		}
+		if task.Version != 0 && task.Version != old.Version {
+			return ErrConflict
+		}
+		stored := *task
+		stored.CreatedAt = old.CreatedAt
+		stored.DeletedAt = ""
+		stored.RepeatText = ""
+		stored.Version = old.Version + 1
+		s.tasks[task.ID] = stored
+		task.Version = stored.Version
+		return nil
+	})
+}
+
+// DeleteTask перемещает задачу с идентификатором id в корзину. Возвращает ошибку, если задача
+// не найдена или уже находится в корзине.
+func (s *MemoryStore) DeleteTask(ctx context.Context, id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.deleteTask(id, 0)
+}
+
+// deleteTask перемещает задачу в корзину и записывает удаление в историю. Ненулевая version - ожидаемая
+// версия задачи. Вызывается при заблокированном хранилище.
+func (s *MemoryStore) deleteTask(id string, version int64) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	return s.revise(id, RevisionDelete, func() error {
+		task, ok := s.tasks[id]
+		if !ok || len(task.DeletedAt) > 0 {
+			return errInvalid("задача не найдена")
+		}
+		if version != 0 && version != task.Version {
+			return ErrConflict
+		}
+		task.DeletedAt = timestamp(time.Now())
+		task.Version++
+		s.tasks[id] = task
+		return nil
+	})
+}
+
+// RestoreTask возвращает задачу с идентификатором id из корзины.
+func (s *MemoryStore) RestoreTask(ctx context.Context, id string) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.revise(id, RevisionRestore, func() error {
+		task, ok := s.tasks[id]
+		if !ok || len(task.DeletedAt) == 0 {
+			return errInvalid("задача в корзине не найдена")
+		}
+		task.DeletedAt = ""
+		task.Version++
+		s.tasks[id] = task
+		return nil
+	})
+}
+
+// PurgeTask окончательно удаляет задачу с идентификатором id из корзины вместе с её исключёнными датами.
+func (s *MemoryStore) PurgeTask(ctx context.Context, id string) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	task, ok := s.tasks[id]
+	if !ok || len(task.DeletedAt) == 0 {
+		return errInvalid("задача в корзине не найдена")
+	}
+	s.purgeTask(id)
+	return nil
+}
+
+// purgeTask окончательно удаляет задачу с идентификатором id и записывает удаление в историю.
+// Вызывается при заблокированном хранилище.
+func (s *MemoryStore) purgeTask(id string) {
+	s.revise(id, RevisionPurge, func() error {
+		delete(s.tasks, id)
+		delete(s.exclusions, id)
+		return nil
+	})
+}
+
+// PurgeTrash окончательно удаляет задачи, перемещённые в корзину раньше времени before.
+// Возвращает количество удалённых задач.
+func (s *MemoryStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var ids []string
+	for id, task := range s.tasks {
+		if len(task.DeletedAt) > 0 && task.DeletedAt < timestamp(before) {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		s.purgeTask(id)
+	}
+	return int64(len(ids)), nil
+}
+
+// Exclusions возвращает упорядоченный список исключённых дат задачи с идентификатором id.
+func (s *MemoryStore) Exclusions(ctx context.Context, id string) ([]string, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	dates := make([]string, 0, len(s.exclusions[id]))
+	for date := range s.exclusions[id] {
+		dates = append(dates, date)
+	}
+	sort.Strings(dates)
+	return dates, nil
+}
+
+// AddExclusion добавляет дату date в список исключённых дат задачи с идентификатором id и, если next
+// не равен nil, обновляет задачу полями next. Ненулевая version - ожидаемая версия задачи (см.
+// SQLiteStore.AddExclusion). Повторное добавление той же даты не является ошибкой.
+func (s *MemoryStore) AddExclusion(ctx context.Context, id, date string, version int64, next *Task) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if next != nil {
+		next.Version = version
+		if err := s.updateTask(next); err != nil {
+			return err
+		}
+	} else if version != 0 {
+		task, ok := s.tasks[id]
+		if !ok || len(task.DeletedAt) > 0 {
+			return errInvalid("задача не найдена")
+		}
+		if task.Version != version {
+			return ErrConflict
+		}
+	}
+	if s.exclusions[id] == nil {
+		s.exclusions[id] = make(map[string]bool)
+	}
+	s.exclusions[id][date] = true
+	return nil
+}
+
+// DeleteExclusion удаляет дату date из списка исключённых дат задачи с идентификатором id.
+func (s *MemoryStore) DeleteExclusion(ctx context.Context, id, date string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.exclusions[id][date] {
+		return errInvalid("исключённая дата не найдена")
+	}
+	delete(s.exclusions[id], date)
+	return nil
+}
+
+// CompleteTask добавляет запись о выполнении c и переносит задачу на следующую дату полями next либо,
+// если next равно nil, перемещает её в корзину. Ненулевая version - ожидаемая версия задачи. Если задача
+// не найдена или изменена, запись не добавляется.
+func (s *MemoryStore) CompleteTask(ctx context.Context, c *Completion, version int64, next *Task) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var err error
+	if next != nil {
+		next.Version = version
+		err = s.updateTask(next)
+	} else {
+		err = s.deleteTask(c.TaskID, version)
+	}
+	if err != nil {
+		return err
+	}
+	stored := *c
+	stored.ID = strconv.Itoa(len(s.completions) + 1)
+	stored.DoneAt = doneAt(c)
+	s.completions = append(s.completions, stored)
+	return nil
+}
+
+// TaskCompletions возвращает записи о выполнении задачи с идентификатором id, упорядоченные по времени
+// выполнения.
+func (s *MemoryStore) TaskCompletions(ctx context.Context, id string) ([]Completion, error) {
+	return s.selectCompletions(func(c Completion) bool { return c.TaskID == id }), nil
+}
+
+// Completions возвращает записи о выполнении со временем выполнения не раньше from и раньше to,
+// упорядоченные по времени выполнения. Нулевое from или to не ограничивает выборку.
+func (s *MemoryStore) Completions(ctx context.Context, from, to time.Time) ([]Completion, error) {
+	return s.selectCompletions(func(c Completion) bool {
+		return c.DoneAt >= rangeBound(from) && (to.IsZero() || c.DoneAt < rangeBound(to))
+	}), nil
+}
+
+// selectCompletions возвращает записи о выполнении, удовлетворяющие match, упорядоченные по времени
+// выполнения и id.
+func (s *MemoryStore) selectCompletions(match func(c Completion) bool) []Completion {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Completion, 0)
+	for _, c := range s.completions {
+		if match(c) {
+			list = append(list, c)
+		}
+	}
+	// Записи добавляются по порядку id, поэтому устойчивая сортировка сохраняет его при равном времени.
+	sort.SliceStable(list, func(i, j int) bool { return list[i].DoneAt < list[j].DoneAt })
+	return list
+}
+
+// revise выполняет изменение change задачи с идентификатором id и записывает его в историю как действие
+// action. Вызывается при заблокированном хранилище.
+func (s *MemoryStore) revise(id, action string, change func() error) error {
+	before := s.snapshot(id)
+	if err := change(); err != nil {
+		return err
+	}
+	s.addRevision(id, action, before, s.snapshot(id))
+	return nil
+}
+
+// snapshot возвращает копию задачи с идентификатором id, в том числе находящейся в корзине, или nil.
+func (s *MemoryStore) snapshot(id string) *Task {
+	task, ok := s.tasks[id]
+	if !ok {
+		return nil
+	}
+	return &task
+}
+
+// addRevision записывает в историю ревизию задачи с идентификатором id. Вызывается при заблокированном
+// хранилище.
+func (s *MemoryStore) addRevision(id, action string, before, after *Task) {
+	if r := newRevision(id, action, before, after); r != nil {
+		r.ID = strconv.Itoa(len(s.revisions) + 1)
+		s.revisions = append(s.revisions, *r)
+	}
+}
+
+// TaskRevisions возвращает историю изменений задачи с идентификатором id, упорядоченную по времени
+// изменения.
+func (s *MemoryStore) TaskRevisions(ctx context.Context, id string) ([]Revision, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	list := make([]Revision, 0)
+	for _, r := range s.revisions {
+		if r.TaskID == id {
+			list = append(list, r)
+		}
+	}
+	return list, nil
+}
+
+// RevertTask возвращает поля задачи с идентификатором id к состоянию после ревизии revisionID,
+// восстанавливая задачу из корзины. Описание ошибок приведено у SQLiteStore.RevertTask.
+func (s *MemoryStore) RevertTask(ctx context.Context, id, revisionID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var target *Task
+	found := false
+	for _, r := range s.revisions {
+		if r.ID == revisionID && r.TaskID == id {
+			target, found = r.After, true
+		}
+	}
+	if !found {
+		return errInvalid("ревизия задачи не найдена")
+	}
+	if target == nil {
+		return errInvalid("после ревизии %s задача не существовала", revisionID)
+	}
+	return s.revise(id, RevisionRevert, func() error {
+		old, ok := s.tasks[id]
+		if !ok {
+			return errInvalid("задача не найдена")
+		}
+		stored := *target
+		stored.ID = id
+		stored.CreatedAt = old.CreatedAt
+		stored.DeletedAt = ""
+		stored.Version = old.Version + 1
+		s.tasks[id] = stored
+		return nil
+	})
+}
+
+// Holidays возвращает список праздничных дней, упорядоченный по дате.
+func (s *MemoryStore) Holidays(ctx context.Context) ([]Holiday, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var list []Holiday
+	for date, title := range s.holidays {
+		list = append(list, Holiday{Date: date, Title: title})
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].Date < list[j].Date })
+	return list, nil
+}
+
+// AddHolidays добавляет праздничные дни из list. Если дата уже присутствует, обновляет её название.
+func (s *MemoryStore) AddHolidays(ctx context.Context, list []Holiday) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, h := range list {
+		s.holidays[h.Date] = h.Title
+	}
+	return nil
+}
+
+// Close ничего не делает: хранилищу в оперативной памяти не требуется освобождать ресурсы.
+func (s *MemoryStore) Close() error {
+	return nil
+}
@@ -0,0 +1,221 @@
+package db
+
+import (
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// migrationFiles содержит миграции схемы базы данных: файлы вида "0001_name.sql" в каталоге своей СУБД,
+// применяемые по порядку номеров. Применённая миграция не изменяется - изменения схемы оформляются
+// новой миграцией.
+//
+//go:embed migrations/sqlite/*.sql migrations/postgres/*.sql
+var migrationFiles embed.FS
+
+// schemaVersionTable содержит команду создания таблицы применённых миграций.
+const schemaVersionTable = `
+CREATE TABLE IF NOT EXISTS schema_version (
+    version INTEGER PRIMARY KEY,
+    name VARCHAR(128) NOT NULL DEFAULT '',
+    applied_at VARCHAR(32) NOT NULL DEFAULT ''
+);
+`
+
+// ErrSchemaTooNew возвращается, если версия схемы базы данных новее последней известной приложению миграции.
+var ErrSchemaTooNew = errors.New("версия схемы базы данных новее поддерживаемой приложением")
+
+// migrationName соответствует имени файла миграции вида "0001_name.sql".
+var migrationName = regexp.MustCompile(`^(\d+)_(\w+)\.sql$`)
+
+// addColumn соответствует команде добавления столбца "ALTER TABLE таблица ADD COLUMN столбец ...".
+var addColumn = regexp.MustCompile(`(?i)^ALTER\s+TABLE\s+(\w+)\s+ADD\s+COLUMN\s+(\w+)`)
+
+// Migration описывает миграцию схемы базы данных с номером версии Version.
+type Migration struct {
+	Version int
+	Name    string
+	SQL     string
+}
+
+// MigrationStatus описывает состояние схемы базы данных: текущую версию Current, последнюю известную
+// приложению версию Latest и ещё не применённые миграции Pending.
+type MigrationStatus struct {
+	Current int
+	Latest  int
+	Pending []Migration
+}
+
+// migrator применяет миграции из каталога dir встроенных файлов к базе данных conn. Запросы tableExists
+// (параметр - имя таблицы) и columnExists (параметры - имена таблицы и столбца) возвращают количество
+// найденных объектов и зависят от СУБД.
+type migrator struct {
+	conn         *sqlx.DB
+	dir          string
+	tableExists  string
+	columnExists string
+}
+
+// migrations возвращает встроенные в приложение миграции, упорядоченные по версии. Версии должны
+// идти подряд, начиная с 1.
+func (m *migrator) migrations() ([]Migration, error) {
+	files, err := fs.Glob(migrationFiles, path.Join("migrations", m.dir, "*.sql"))
+	if err != nil {
+		return nil, err
+	}
+	var migrations []Migration
+	for _, file := range files {
+		match := migrationName.FindStringSubmatch(path.Base(file))
+		if match == nil {
+			return nil, fmt.Errorf("недопустимое имя файла миграции '%s'", file)
+		}
+		version, _ := strconv.Atoi(match[1])
+		text, err := migrationFiles.ReadFile(file)
+		if err != nil {
+			return nil, err
+		}
+		migrations = append(migrations, Migration{Version: version, Name: match[2], SQL: string(text)})
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	for i, m := range migrations {
+		if m.Version != i+1 {
+			return nil, fmt.Errorf("пропущена или повторяется миграция версии %d", i+1)
+		}
+	}
+	return migrations, nil
+}
+
+// String возвращает имя миграции вида "0001_name".
+func (m Migration) String() string {
+	return fmt.Sprintf("%04d_%s", m.Version, m.Name)
+}
+
+// Status возвращает состояние схемы базы данных относительно встроенных миграций.
+func (m *migrator) Status() (*MigrationStatus, error) {
+	migrations, err := m.migrations()
+	if err != nil {
+		return nil, err
+	}
+	current, err := m.schemaVersion()
+	if err != nil {
+		return nil, err
+	}
+	status := &MigrationStatus{Current: current, Latest: len(migrations)}
+	if current < len(migrations) {
+		status.Pending = migrations[current:]
+	}
+	return status, nil
+}
+
+// Migrate применяет к базе данных недостающие миграции в одной транзакции: при ошибке схема остаётся
+// прежней. Если dryRun, транзакция откатывается после применения миграций, что позволяет проверить их
+// без изменения базы данных. Возвращает применённые миграции. Если схема базы данных новее
+// приложения, возвращает ErrSchemaTooNew.
+//
+// Базы данных, созданные до появления миграций, не содержат таблицы schema_version, поэтому к ним
+// применяются все миграции. Для этого команды миграций идемпотентны: таблицы, индексы и триггеры
+// создаются с IF NOT EXISTS, а уже существующие столбцы не добавляются повторно.
+func (m *migrator) Migrate(dryRun bool) ([]Migration, error) {
+	status, err := m.Status()
+	if err != nil {
+		return nil, err
+	}
+	if status.Current > status.Latest {
+		return nil, fmt.Errorf("%w: версия %d, приложение поддерживает до %d", ErrSchemaTooNew,
+			status.Current, status.Latest)
+	}
+	if len(status.Pending) == 0 {
+		return nil, nil
+	}
+	tx, err := m.conn.Beginx()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+	if _, err := tx.Exec(schemaVersionTable); err != nil {
+		return nil, err
+	}
+	for _, migration := range status.Pending {
+		if err := m.apply(tx, migration); err != nil {
+			return nil, fmt.Errorf("миграция %s: %w", migration, err)
+		}
+	}
+	if dryRun {
+		return status.Pending, nil
+	}
+	return status.Pending, tx.Commit()
+}
+
+// apply выполняет команды миграции migration в транзакции tx и записывает её в таблицу schema_version.
+func (m *migrator) apply(tx *sqlx.Tx, migration Migration) error {
+	for _, stmt := range statements(migration.SQL) {
+		if match := addColumn.FindStringSubmatch(stmt); match != nil {
+			var count int
+			if err := tx.Get(&count, tx.Rebind(m.columnExists), match[1], match[2]); err != nil {
+				return err
+			}
+			if count > 0 {
+				continue
+			}
+		}
+		if _, err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	_, err := tx.Exec(tx.Rebind(`INSERT INTO schema_version (version, name, applied_at) VALUES (?, ?, ?)`),
+		migration.Version, migration.Name, time.Now().UTC().Format(time.RFC3339))
+	return err
+}
+
+// schemaVersion возвращает версию схемы базы данных: номер последней применённой миграции или 0,
+// если таблица schema_version отсутствует.
+func (m *migrator) schemaVersion() (int, error) {
+	var count int
+	err := m.conn.Get(&count, m.conn.Rebind(m.tableExists), "schema_version")
+	if err != nil || count == 0 {
+		return 0, err
+	}
+	var version int
+	err = m.conn.Get(&version, `SELECT coalesce(max(version), 0) FROM schema_version`)
+	return version, err
+}
+
+// statements разбивает текст миграции на отдельные команды. Команда заканчивается точкой с запятой
+// в конце строки, кроме команд внутри блока BEGIN ... END триггера. Строки комментариев вне команд
+// пропускаются.
+func statements(script string) []string {
+	var res []string
+	var stmt strings.Builder
+	inBlock := false
+	for _, line := range strings.Split(script, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if stmt.Len() == 0 && (len(trimmed) == 0 || strings.HasPrefix(trimmed, "--")) {
+			continue
+		}
+		stmt.WriteString(line)
+		stmt.WriteString("\n")
+		switch strings.ToUpper(trimmed) {
+		case "BEGIN":
+			inBlock = true
+		case "END;":
+			inBlock = false
+		}
+		if !inBlock && strings.HasSuffix(trimmed, ";") {
+			res = append(res, strings.TrimSpace(stmt.String()))
+			stmt.Reset()
+		}
+	}
+	if rest := strings.TrimSpace(stmt.String()); len(rest) > 0 {
+		res = append(res, rest)
+	}
+	return res
+}
@@ -0,0 +1,22 @@
+CREATE TABLE IF NOT EXISTS scheduler (
+    id BIGSERIAL PRIMARY KEY,
+    date VARCHAR(8) NOT NULL DEFAULT '',
+    title VARCHAR(256) NOT NULL DEFAULT '',
+    comment TEXT NOT NULL DEFAULT '',
+    repeat VARCHAR(128) NOT NULL DEFAULT '',
+    until VARCHAR(8) NOT NULL DEFAULT '',
+    remaining INTEGER NOT NULL DEFAULT 0,
+    after_done BOOLEAN NOT NULL DEFAULT FALSE,
+    time VARCHAR(5) NOT NULL DEFAULT '',
+    tz VARCHAR(64) NOT NULL DEFAULT ''
+);
+CREATE INDEX IF NOT EXISTS scheduler_date ON scheduler (date);
+CREATE TABLE IF NOT EXISTS holidays (
+    date VARCHAR(8) PRIMARY KEY,
+    title VARCHAR(256) NOT NULL DEFAULT ''
+);
+CREATE TABLE IF NOT EXISTS scheduler_exclusions (
+    task_id BIGINT NOT NULL REFERENCES scheduler (id) ON DELETE CASCADE,
+    date VARCHAR(8) NOT NULL,
+    PRIMARY KEY (task_id, date)
+);
@@ -0,0 +1,7 @@
+ALTER TABLE scheduler ADD COLUMN created_at VARCHAR(20) NOT NULL
+    DEFAULT to_char(now() AT TIME ZONE 'UTC', 'YYYY-MM-DD"T"HH24:MI:SS"Z"');
+-- Индексы для упорядочивания списка задач. Заголовки упорядочиваются посимвольно, как в SQLite.
+DROP INDEX IF EXISTS scheduler_date;
+CREATE INDEX IF NOT EXISTS scheduler_date_time ON scheduler (date, time, id);
+CREATE INDEX IF NOT EXISTS scheduler_title ON scheduler (title COLLATE "C", id);
+CREATE INDEX IF NOT EXISTS scheduler_created_at ON scheduler (created_at, id);
@@ -0,0 +1,11 @@
+-- История изменений задач: состояния задачи до и после каждого изменения в формате json (пустая
+-- строка - задачи не существовало). Записи не удаляются вместе с задачей.
+CREATE TABLE IF NOT EXISTS task_revisions (
+    id BIGSERIAL PRIMARY KEY,
+    task_id BIGINT NOT NULL,
+    action VARCHAR(16) NOT NULL,
+    before_state TEXT NOT NULL DEFAULT '',
+    after_state TEXT NOT NULL DEFAULT '',
+    changed_at VARCHAR(20) NOT NULL
+);
+CREATE INDEX IF NOT EXISTS task_revisions_task_id ON task_revisions (task_id, changed_at);
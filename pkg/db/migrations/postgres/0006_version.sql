@@ -0,0 +1,2 @@
+-- Версия задачи для оптимистической блокировки: увеличивается при каждом изменении задачи.
+ALTER TABLE scheduler ADD COLUMN version BIGINT NOT NULL DEFAULT 1;
@@ -0,0 +1,4 @@
+CREATE TABLE IF NOT EXISTS holidays (
+    date CHAR(8) PRIMARY KEY,
+    title VARCHAR(256) NOT NULL DEFAULT ""
+);
@@ -0,0 +1,11 @@
+-- Исключённые даты удаляются вместе с задачей триггером, так как проверка внешних ключей
+-- в SQLite по умолчанию отключена.
+CREATE TABLE IF NOT EXISTS scheduler_exclusions (
+    task_id INTEGER NOT NULL REFERENCES scheduler (id) ON DELETE CASCADE,
+    date CHAR(8) NOT NULL,
+    PRIMARY KEY (task_id, date)
+);
+CREATE TRIGGER IF NOT EXISTS scheduler_delete_exclusions AFTER DELETE ON scheduler
+BEGIN
+    DELETE FROM scheduler_exclusions WHERE task_id = OLD.id;
+END;
@@ -0,0 +1,2 @@
+ALTER TABLE scheduler ADD COLUMN until CHAR(8) NOT NULL DEFAULT "";
+ALTER TABLE scheduler ADD COLUMN remaining INTEGER NOT NULL DEFAULT 0;
@@ -0,0 +1 @@
+ALTER TABLE scheduler ADD COLUMN after_done INTEGER NOT NULL DEFAULT 0;
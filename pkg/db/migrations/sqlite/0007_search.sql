@@ -0,0 +1,24 @@
+-- Полнотекстовый индекс заголовков и комментариев задач. Индекс хранит только токены, а текст
+-- берётся из таблицы scheduler; синхронизацию обеспечивают триггеры. Токенизатор unicode61 приводит
+-- к одному регистру буквы любого алфавита и отбрасывает диакритические знаки ("ё" совпадает с "е").
+CREATE VIRTUAL TABLE IF NOT EXISTS scheduler_fts USING fts5(
+    title,
+    comment,
+    content = 'scheduler',
+    content_rowid = 'id',
+    tokenize = 'unicode61 remove_diacritics 2'
+);
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_insert AFTER INSERT ON scheduler
+BEGIN
+    INSERT INTO scheduler_fts (rowid, title, comment) VALUES (NEW.id, NEW.title, NEW.comment);
+END;
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_delete AFTER DELETE ON scheduler
+BEGIN
+    INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', OLD.id, OLD.title, OLD.comment);
+END;
+CREATE TRIGGER IF NOT EXISTS scheduler_fts_update AFTER UPDATE OF title, comment ON scheduler
+BEGIN
+    INSERT INTO scheduler_fts (scheduler_fts, rowid, title, comment) VALUES ('delete', OLD.id, OLD.title, OLD.comment);
+    INSERT INTO scheduler_fts (rowid, title, comment) VALUES (NEW.id, NEW.title, NEW.comment);
+END;
+INSERT INTO scheduler_fts (scheduler_fts) VALUES ('rebuild');
@@ -0,0 +1,14 @@
+-- SQLite не позволяет добавить столбец со значением по умолчанию CURRENT_TIMESTAMP, поэтому время
+-- создания задачи, не заданное при добавлении, проставляет триггер. Существующим задачам присваивается
+-- время применения миграции.
+ALTER TABLE scheduler ADD COLUMN created_at VARCHAR(20) NOT NULL DEFAULT "";
+UPDATE scheduler SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE created_at = "";
+CREATE TRIGGER IF NOT EXISTS scheduler_created_at AFTER INSERT ON scheduler WHEN NEW.created_at = ""
+BEGIN
+    UPDATE scheduler SET created_at = strftime('%Y-%m-%dT%H:%M:%SZ', 'now') WHERE id = NEW.id;
+END;
+-- Индексы для упорядочивания списка задач. id входит в каждый индекс SQLite как rowid.
+DROP INDEX IF EXISTS scheduler_date;
+CREATE INDEX IF NOT EXISTS scheduler_date_time ON scheduler (date, time);
+CREATE INDEX IF NOT EXISTS scheduler_title ON scheduler (title);
+CREATE INDEX IF NOT EXISTS scheduler_created_at ON scheduler (created_at);
@@ -0,0 +1,9 @@
+-- Удалённые задачи остаются в таблице с временем удаления до очистки корзины. Индексы упорядочивания
+-- списка задач начинаются с deleted_at, так как список выбирает только действующие задачи.
+ALTER TABLE scheduler ADD COLUMN deleted_at VARCHAR(20) NOT NULL DEFAULT "";
+DROP INDEX IF EXISTS scheduler_date_time;
+DROP INDEX IF EXISTS scheduler_title;
+DROP INDEX IF EXISTS scheduler_created_at;
+CREATE INDEX IF NOT EXISTS scheduler_deleted_date_time ON scheduler (deleted_at, date, time);
+CREATE INDEX IF NOT EXISTS scheduler_deleted_title ON scheduler (deleted_at, title);
+CREATE INDEX IF NOT EXISTS scheduler_deleted_created_at ON scheduler (deleted_at, created_at);
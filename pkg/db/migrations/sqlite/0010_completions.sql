@@ -0,0 +1,12 @@
+-- История выполнения задач. Записи не удаляются вместе с задачей, поэтому task_id не является
+-- внешним ключом.
+CREATE TABLE IF NOT EXISTS task_completions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    task_id INTEGER NOT NULL,
+    title VARCHAR(256) NOT NULL DEFAULT "",
+    date CHAR(8) NOT NULL DEFAULT "",
+    done_at VARCHAR(20) NOT NULL,
+    note TEXT NOT NULL DEFAULT ""
+);
+CREATE INDEX IF NOT EXISTS task_completions_task_id ON task_completions (task_id, done_at);
+CREATE INDEX IF NOT EXISTS task_completions_done_at ON task_completions (done_at);
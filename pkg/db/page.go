@@ -0,0 +1,244 @@
+package db
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"slices"
+	"strconv"
+	"strings"
+
+	"go1f/pkg/filter"
+)
+
+// SortOrder - порядок задач в выборке.
+type SortOrder string
+
+const (
+	SortDate     SortOrder = "date"    // По дате, времени и id
+	SortDateDesc SortOrder = "-date"   // По дате, времени и id в обратном порядке
+	SortTitle    SortOrder = "title"   // По заголовку (посимвольно, с учётом регистра) и id
+	SortCreated  SortOrder = "created" // По времени создания и id
+)
+
+// SortOrders содержит все допустимые порядки задач.
+var SortOrders = []SortOrder{SortDate, SortDateDesc, SortTitle, SortCreated}
+
+// TaskQuery описывает выборку задач: дерево условий запроса поиска Filter (nil - все задачи), порядок
+// задач Sort, размер страницы Limit и курсор Cursor, полученный с предыдущей страницей (пустая строка -
+// первая страница). Пустой Sort означает упорядочивание по релевантности при поиске по словам и SortDate
+// без него. Trash выбирает задачи из корзины вместо действующих.
+type TaskQuery struct {
+	Filter filter.Node
+	Sort   SortOrder
+	Limit  int
+	Cursor string
+	Trash  bool
+}
+
+// TaskPage - страница задач. Total содержит количество всех задач, соответствующих запросу, NextCursor -
+// курсор следующей страницы (пустая строка, если страница последняя).
+type TaskPage struct {
+	Tasks      []*Task
+	Total      int
+	NextCursor string
+}
+
+// validate проверяет параметры выборки задач.
+func (q TaskQuery) validate() error {
+	if q.Limit < 1 {
+		return errInvalid("недопустимый размер страницы: %d", q.Limit)
+	}
+	if len(q.Sort) > 0 && !slices.Contains(SortOrders, q.Sort) {
+		return errInvalid("недопустимый порядок задач '%s'", q.Sort)
+	}
+	return nil
+}
+
+// relevance проверяет, упорядочиваются ли задачи выборки сначала по релевантности.
+func (q TaskQuery) relevance() bool {
+	return len(q.Sort) == 0 && len(filter.TextTerms(q.Filter)) > 0
+}
+
+// order возвращает порядок задач выборки, сохраняемый в курсоре: пустую строку при упорядочивании
+// по релевантности, SortDate, если порядок не указан, иначе q.Sort.
+func (q TaskQuery) order() SortOrder {
+	if len(q.Sort) == 0 && !q.relevance() {
+		return SortDate
+	}
+	return q.Sort
+}
+
+// taskWhere возвращает условие WHERE с параметрами "?" для таблицы scheduler с псевдонимом s: задачи
+// корзины или действующие задачи в соответствии с q.Trash, удовлетворяющие дереву условий q.Filter.
+func taskWhere(q TaskQuery, text textCondition) (string, []any) {
+	where := `s.deleted_at = ''`
+	if q.Trash {
+		where = `s.deleted_at <> ''`
+	}
+	cond, args := compileFilter(q.Filter, text)
+	if len(cond) > 0 {
+		where += ` AND ` + cond
+	}
+	return where, args
+}
+
+// sortKey - выражение SQL, по которому упорядочиваются задачи, и направление упорядочивания.
+type sortKey struct {
+	expr string
+	desc bool
+}
+
+// sortKeys возвращает ключи упорядочивания задач таблицы scheduler с псевдонимом s в порядке order.
+// Последним ключом всегда является id, поэтому порядок строк однозначен.
+func sortKeys(order SortOrder) []sortKey {
+	switch order {
+	case SortDateDesc:
+		return []sortKey{{expr: `s.date`, desc: true}, {expr: `s.time`, desc: true}, {expr: `s.id`, desc: true}}
+	case SortTitle:
+		return []sortKey{{expr: `s.title`}, {expr: `s.id`}}
+	case SortCreated:
+		return []sortKey{{expr: `s.created_at`}, {expr: `s.id`}}
+	}
+	return []sortKey{{expr: `s.date`}, {expr: `s.time`}, {expr: `s.id`}}
+}
+
+// sortValues возвращает значения ключей sortKeys(order) задачи task.
+func sortValues(task *Task, order SortOrder) []any {
+	id, _ := strconv.ParseInt(task.ID, 10, 64)
+	switch order {
+	case SortTitle:
+		return []any{task.Title, id}
+	case SortCreated:
+		return []any{task.CreatedAt, id}
+	}
+	return []any{task.Date, task.Time, id}
+}
+
+// orderBy возвращает список выражений ORDER BY для ключей keys.
+func orderBy(keys []sortKey) string {
+	var order []string
+	for _, key := range keys {
+		if key.desc {
+			order = append(order, key.expr+` DESC`)
+		} else {
+			order = append(order, key.expr)
+		}
+	}
+	return strings.Join(order, `, `)
+}
+
+// keysetCondition возвращает условие SQL с параметрами "?", которому соответствуют строки, следующие
+// в порядке keys за строкой со значениями ключей values: (k1 > v1) OR (k1 = v1 AND k2 > v2) OR ...
+// Для ключей с обратным направлением используется "<". Условие дополняется избыточной границей
+// k1 >= v1, чтобы СУБД могла начать просмотр индекса по первому ключу с нужного места. Выражения
+// ключей заключаются в скобки, так как могут содержать операторы с низким приоритетом (IS NULL).
+func keysetCondition(keys []sortKey, values []any) (string, []any) {
+	exprs := make([]string, len(keys))
+	for i, key := range keys {
+		exprs[i] = "(" + key.expr + ")"
+	}
+	bound := exprs[0] + " >= ?"
+	if keys[0].desc {
+		bound = exprs[0] + " <= ?"
+	}
+	var ors []string
+	args := []any{values[0]}
+	for i, key := range keys {
+		var ands []string
+		for j := 0; j < i; j++ {
+			ands = append(ands, exprs[j]+" = ?")
+			args = append(args, values[j])
+		}
+		op := " > ?"
+		if key.desc {
+			op = " < ?"
+		}
+		ands = append(ands, exprs[i]+op)
+		args = append(args, values[i])
+		ors = append(ors, "("+strings.Join(ands, " AND ")+")")
+	}
+	return "(" + bound + " AND (" + strings.Join(ors, " OR ") + "))", args
+}
+
+// encodeCursor кодирует порядок задач order и значения ключей упорядочивания последней строки страницы
+// в непрозрачный курсор.
+func encodeCursor(order SortOrder, values []any) string {
+	data, _ := json.Marshal(append([]any{string(order)}, values...))
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// decodeCursor декодирует курсор s, содержащий значения n ключей упорядочивания. Курсор, полученный
+// при другом порядке задач, чем order, недопустим. Целые числа декодируются как int64, дробные -
+// как float64.
+func decodeCursor(s string, order SortOrder, n int) ([]any, error) {
+	errCursor := errInvalid("недопустимый курсор '%s'", s)
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, errCursor
+	}
+	var values []any
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&values); err != nil || len(values) != n+1 || values[0] != string(order) {
+		return nil, errCursor
+	}
+	values = values[1:]
+	for i, v := range values {
+		switch v := v.(type) {
+		case json.Number:
+			if n, err := v.Int64(); err == nil {
+				values[i] = n
+			} else if f, err := v.Float64(); err == nil {
+				values[i] = f
+			} else {
+				return nil, errCursor
+			}
+		case string, bool:
+		default:
+			return nil, errCursor
+		}
+	}
+	return values, nil
+}
+
+// compareKeys сравнивает значения ключей упорядочивания a и b с учётом направлений keys. Возвращает -1,
+// если a предшествует b, 1 - если следует за b, и 0 при равенстве.
+func compareKeys(keys []sortKey, a, b []any) int {
+	for i, key := range keys {
+		c := compareValues(a[i], b[i])
+		if key.desc {
+			c = -c
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// compareValues сравнивает значения ключа одного типа: строки, целые числа или логические значения.
+func compareValues(a, b any) int {
+	switch a := a.(type) {
+	case string:
+		b, _ := b.(string)
+		return strings.Compare(a, b)
+	case int64:
+		b, _ := b.(int64)
+		switch {
+		case a < b:
+			return -1
+		case a > b:
+			return 1
+		}
+	case bool:
+		b, _ := b.(bool)
+		switch {
+		case !a && b:
+			return -1
+		case a && !b:
+			return 1
+		}
+	}
+	return 0
+}
@@ -0,0 +1,286 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// Действия над задачей, записываемые в историю изменений.
+const (
+	RevisionCreate  = "create"  // Добавление задачи
+	RevisionUpdate  = "update"  // Изменение полей задачи, в том числе перенос при выполнении
+	RevisionDelete  = "delete"  // Перемещение задачи в корзину
+	RevisionRestore = "restore" // Возврат задачи из корзины
+	RevisionPurge   = "purge"   // Окончательное удаление задачи
+	RevisionRevert  = "revert"  // Возврат полей задачи к состоянию после одной из предыдущих ревизий
+)
+
+// Revision соответствует полям таблицы task_revisions - записи об изменении задачи с идентификатором
+// TaskID действием Action. Before и After содержат состояние задачи до и после изменения (nil - задачи
+// не существовало), ChangedAt - время изменения в UTC в формате RFC 3339. Записи сохраняются и после
+// окончательного удаления задачи.
+type Revision struct {
+	ID        string `json:"id"`
+	TaskID    string `json:"task_id"`
+	Action    string `json:"action"`
+	Before    *Task  `json:"before,omitempty"`
+	After     *Task  `json:"after,omitempty"`
+	ChangedAt string `json:"changed_at"`
+}
+
+// FieldChange описывает изменение поля задачи Field со значения Before на значение After.
+type FieldChange struct {
+	Field  string `json:"field"`
+	Before string `json:"before"`
+	After  string `json:"after"`
+}
+
+// taskFields возвращает названия и значения хранимых полей задачи task, которые сравниваются при
+// построении списка изменений. Для nil возвращает пустые значения.
+func taskFields(task *Task) [][2]string {
+	if task == nil {
+		task = &Task{}
+	}
+	remaining := ""
+	if task.Remaining != 0 {
+		remaining = strconv.Itoa(task.Remaining)
+	}
+	afterDone := ""
+	if task.AfterDone {
+		afterDone = strconv.FormatBool(task.AfterDone)
+	}
+	return [][2]string{
+		{"date", task.Date},
+		{"title", task.Title},
+		{"comment", task.Comment},
+		{"repeat", task.Repeat},
+		{"until", task.Until},
+		{"remaining", remaining},
+		{"after_done", afterDone},
+		{"time", task.Time},
+		{"tz", task.TZ},
+		{"deleted_at", task.DeletedAt},
+	}
+}
+
+// Changes возвращает список полей задачи, изменённых ревизией, в порядке полей задачи.
+func (r Revision) Changes() []FieldChange {
+	changes := make([]FieldChange, 0)
+	before, after := taskFields(r.Before), taskFields(r.After)
+	for i := range before {
+		if before[i][1] != after[i][1] {
+			changes = append(changes, FieldChange{Field: before[i][0], Before: before[i][1], After: after[i][1]})
+		}
+	}
+	return changes
+}
+
+// newRevision возвращает ревизию задачи с идентификатором id с текущим временем изменения. Если действие
+// не изменило ни одного поля задачи, возвращает nil: такие ревизии не записываются.
+func newRevision(id, action string, before, after *Task) *Revision {
+	r := &Revision{TaskID: id, Action: action, Before: before, After: after, ChangedAt: timestamp(time.Now())}
+	if before != nil && after != nil && len(r.Changes()) == 0 {
+		return nil
+	}
+	return r
+}
+
+// encodeSnapshot кодирует состояние задачи task для хранения в базе данных: json или пустая строка для nil.
+func encodeSnapshot(task *Task) (string, error) {
+	if task == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(task)
+	return string(data), err
+}
+
+// decodeSnapshot декодирует состояние задачи, закодированное encodeSnapshot.
+func decodeSnapshot(s string) (*Task, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+	var task Task
+	if err := json.Unmarshal([]byte(s), &task); err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// revisionRow соответствует строке таблицы task_revisions.
+type revisionRow struct {
+	ID          string `db:"id"`
+	TaskID      string `db:"task_id"`
+	Action      string `db:"action"`
+	BeforeState string `db:"before_state"`
+	AfterState  string `db:"after_state"`
+	ChangedAt   string `db:"changed_at"`
+}
+
+// revision декодирует строку таблицы task_revisions в ревизию.
+func (row revisionRow) revision() (Revision, error) {
+	r := Revision{ID: row.ID, TaskID: row.TaskID, Action: row.Action, ChangedAt: row.ChangedAt}
+	var err error
+	if r.Before, err = decodeSnapshot(row.BeforeState); err != nil {
+		return r, err
+	}
+	r.After, err = decodeSnapshot(row.AfterState)
+	return r, err
+}
+
+// revisions декодирует строки таблицы task_revisions.
+func revisions(rows []revisionRow) ([]Revision, error) {
+	list := make([]Revision, 0, len(rows))
+	for _, row := range rows {
+		r, err := row.revision()
+		if err != nil {
+			return list, err
+		}
+		list = append(list, r)
+	}
+	return list, nil
+}
+
+// inTx выполняет fn в транзакции базы данных SQLite. Транзакция фиксируется, если fn не вернула ошибку.
+func (s *SQLiteStore) inTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := s.db.BeginTxx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// snapshot возвращает текущее состояние задачи с идентификатором id, в том числе находящейся в корзине,
+// или nil, если задачи нет.
+func snapshot(ctx context.Context, tx *sqlx.Tx, id string) (*Task, error) {
+	var task Task
+	query := `SELECT id, date, title, comment, repeat, until, remaining, after_done, time, tz, created_at, deleted_at,
+	version FROM scheduler WHERE id = :id`
+
+	err := tx.QueryRowContext(ctx, query, sql.Named("id", id)).Scan(&task.ID, &task.Date, &task.Title, &task.Comment,
+		&task.Repeat, &task.Until, &task.Remaining, &task.AfterDone, &task.Time, &task.TZ, &task.CreatedAt,
+		&task.DeletedAt, &task.Version)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &task, nil
+}
+
+// addRevision записывает в таблицу task_revisions ревизию задачи с идентификатором id, изменённой
+// действием action из состояния before в состояние after.
+func addRevision(ctx context.Context, tx *sqlx.Tx, id, action string, before, after *Task) error {
+	r := newRevision(id, action, before, after)
+	if r == nil {
+		return nil
+	}
+	beforeState, err := encodeSnapshot(before)
+	if err != nil {
+		return err
+	}
+	afterState, err := encodeSnapshot(after)
+	if err != nil {
+		return err
+	}
+	query := `INSERT INTO task_revisions (task_id, action, before_state, after_state, changed_at)
+	VALUES (:task_id, :action, :before_state, :after_state, :changed_at)`
+
+	_, err = tx.ExecContext(ctx, query,
+		sql.Named("task_id", id),
+		sql.Named("action", action),
+		sql.Named("before_state", beforeState),
+		sql.Named("after_state", afterState),
+		sql.Named("changed_at", r.ChangedAt))
+	return err
+}
+
+// revise выполняет в транзакции tx изменение change задачи с идентификатором id и записывает его
+// в историю как действие action с состояниями задачи до и после изменения.
+func revise(ctx context.Context, tx *sqlx.Tx, id, action string, change func() error) error {
+	before, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if err := change(); err != nil {
+		return err
+	}
+	after, err := snapshot(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	return addRevision(ctx, tx, id, action, before, after)
+}
+
+// TaskRevisions возвращает историю изменений задачи с идентификатором id, упорядоченную по времени
+// изменения, и возможную ошибку.
+func (s *SQLiteStore) TaskRevisions(ctx context.Context, id string) ([]Revision, error) {
+	var rows []revisionRow
+	query := `SELECT id, task_id, action, before_state, after_state, changed_at FROM task_revisions
+	WHERE task_id = :id ORDER BY changed_at, id`
+
+	if err := s.db.SelectContext(ctx, &rows, query, sql.Named("id", id)); err != nil {
+		return make([]Revision, 0), err
+	}
+	return revisions(rows)
+}
+
+// RevertTask возвращает поля задачи с идентификатором id к состоянию после ревизии revisionID.
+// Задача из корзины при этом восстанавливается. Возврат записывается в историю как отдельная ревизия.
+// Возвращает ошибку, если ревизия не относится к задаче, задача после ревизии не существовала или
+// задача окончательно удалена.
+func (s *SQLiteStore) RevertTask(ctx context.Context, id, revisionID string) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var row revisionRow
+		query := `SELECT id, task_id, action, before_state, after_state, changed_at FROM task_revisions
+		WHERE id = :id AND task_id = :task_id`
+
+		err := tx.GetContext(ctx, &row, query, sql.Named("id", revisionID), sql.Named("task_id", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return errInvalid("ревизия задачи не найдена")
+		}
+		if err != nil {
+			return err
+		}
+		r, err := row.revision()
+		if err != nil {
+			return err
+		}
+		if r.After == nil {
+			return errInvalid("после ревизии %s задача не существовала", revisionID)
+		}
+		return revise(ctx, tx, id, RevisionRevert, func() error {
+			target := *r.After
+			target.ID = id
+			query := `UPDATE scheduler SET date = :date, title = :title, comment = :comment, repeat = :repeat,
+			until = :until, remaining = :remaining, after_done = :after_done, time = :time, tz = :tz, deleted_at = '',
+			version = version + 1 WHERE id = :id`
+
+			res, err := tx.ExecContext(ctx, query,
+				sql.Named("id", id),
+				sql.Named("date", target.Date),
+				sql.Named("title", target.Title),
+				sql.Named("comment", target.Comment),
+				sql.Named("repeat", target.Repeat),
+				sql.Named("until", target.Until),
+				sql.Named("remaining", target.Remaining),
+				sql.Named("after_done", target.AfterDone),
+				sql.Named("time", target.Time),
+				sql.Named("tz", target.TZ))
+			if err != nil {
+				return err
+			}
+			return checkAffected(res, "задача не найдена")
+		})
+	})
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"strings"
+	"unicode"
+)
+
+const (
+	SnippetStart  = "<mark>"  // Начало выделения найденного слова во фрагменте текста задачи
+	SnippetEnd    = "</mark>" // Конец выделения найденного слова во фрагменте текста задачи
+	SnippetTokens = 12        // Максимальное количество слов во фрагменте текста задачи
+)
+
+// searchTerms разбивает строку поиска search на слова: последовательности букв и цифр.
+func searchTerms(search string) []string {
+	return strings.FieldsFunc(search, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+}
+
+// ftsQuery формирует выражение полнотекстового поиска FTS5 из строки поиска search: задача должна
+// содержать все слова строки, причём каждое слово может быть началом слова задачи ("отч" находит
+// "отчёт"). Слова заключаются в кавычки, поэтому операторы FTS5 в строке поиска не действуют.
+// Если в строке нет слов, возвращает пустую строку.
+func ftsQuery(search string) string {
+	var parts []string
+	for _, term := range searchTerms(search) {
+		parts = append(parts, `"`+term+`"*`)
+	}
+	return strings.Join(parts, " ")
+}
@@ -0,0 +1,63 @@
+package db
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// SQLiteStore - хранилище задач в файле базы данных SQLite.
+type SQLiteStore struct {
+	migrator
+	db *sqlx.DB
+}
+
+// NewSQLiteStore устанавливает соединение с базой данных SQLite в файле path. Если файл отсутствует,
+// он будет создан.
+func NewSQLiteStore(path string) (*SQLiteStore, error) {
+	db, err := sqlx.Open("sqlite", sqliteDSN(path))
+	if err != nil {
+		return nil, err
+	}
+	return &SQLiteStore{
+		migrator: migrator{
+			conn:         db,
+			dir:          "sqlite",
+			tableExists:  `SELECT count(*) FROM sqlite_master WHERE type = 'table' AND name = ?`,
+			columnExists: `SELECT count(*) FROM pragma_table_info(?) WHERE name = ?`,
+		},
+		db: db,
+	}, nil
+}
+
+// Close закрывает соединение с базой данных.
+func (s *SQLiteStore) Close() error {
+	return s.db.Close()
+}
+
+// sqliteDSN дополняет путь path параметрами соединений с базой данных. busy_timeout заставляет запрос,
+// встретивший блокировку другого соединения, ждать её снятия в пределах срока операции, а не сразу
+// завершаться ошибкой SQLITE_BUSY. Транзакции начинаются командой BEGIN IMMEDIATE: транзакция сразу
+// получает блокировку записи, и параллельные изменения задачи выполняются по очереди, а не прерываются
+// взаимной блокировкой при переходе от чтения к записи.
+func sqliteDSN(path string) string {
+	sep := "?"
+	if strings.Contains(path, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s_pragma=busy_timeout(%d)&_txlock=immediate", path, sep, getTimeout().Milliseconds())
+}
+
+// sqliteBusy проверяет, вызвана ли ошибка err блокировкой базы данных SQLite другим соединением.
+func sqliteBusy(err error) bool {
+	var e *sqlite.Error
+	if !errors.As(err, &e) {
+		return false
+	}
+	code := e.Code() & 0xff
+	return code == sqlite3.SQLITE_BUSY || code == sqlite3.SQLITE_LOCKED
+}
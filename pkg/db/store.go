@@ -0,0 +1,313 @@
+package db
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// MemoryDSN содержит строку подключения к хранилищу задач в оперативной памяти.
+const MemoryDSN = "memory:"
+
+// TaskStore описывает хранилище задач вместе с их исключёнными датами и календарём праздничных дней.
+// Реализации: SQLiteStore (по умолчанию), MemoryStore (для тестов и демонстрации) и PostgresStore.
+// Методы принимают контекст запроса: при его отмене или истечении срока обращение к базе данных
+// прерывается.
+//
+// Условия на слова в Tasks реализации проверяют по-разному, поэтому один и тот же запрос может вернуть
+// разные задачи. SQLiteStore и MemoryStore ищут слова, начинающиеся со слова запроса ("отч" находит
+// "отчёт", но не "учёт"). SQLiteStore вдобавок упорядочивает задачи по релевантности и заполняет Snippet.
+// PostgresStore ищет слово запроса как подстроку без учёта регистра ("чёт" находит и "отчёт", и "учёт").
+// Он не упорядочивает задачи по релевантности и оставляет Snippet пустым.
+type TaskStore interface {
+	AddTask(ctx context.Context, task *Task) (int64, error)
+	Tasks(ctx context.Context, q TaskQuery) (*TaskPage, error)
+	GetTask(ctx context.Context, id string) (*Task, error)
+	UpdateTask(ctx context.Context, task *Task) error
+	DeleteTask(ctx context.Context, id string) error
+	RestoreTask(ctx context.Context, id string) error
+	PurgeTask(ctx context.Context, id string) error
+	PurgeTrash(ctx context.Context, before time.Time) (int64, error)
+	CompleteTask(ctx context.Context, c *Completion, version int64, next *Task) error
+	TaskCompletions(ctx context.Context, id string) ([]Completion, error)
+	Completions(ctx context.Context, from, to time.Time) ([]Completion, error)
+	TaskRevisions(ctx context.Context, id string) ([]Revision, error)
+	RevertTask(ctx context.Context, id, revisionID string) error
+	Exclusions(ctx context.Context, id string) ([]string, error)
+	AddExclusion(ctx context.Context, id, date string, version int64, next *Task) error
+	DeleteExclusion(ctx context.Context, id, date string) error
+	Holidays(ctx context.Context) ([]Holiday, error)
+	AddHolidays(ctx context.Context, list []Holiday) error
+	Close() error
+}
+
+// Migrator реализуется хранилищами, схема которых обновляется миграциями.
+type Migrator interface {
+	Status() (*MigrationStatus, error)
+	Migrate(dryRun bool) ([]Migration, error)
+}
+
+var store TaskStore // хранилище задач, с которым работают функции пакета.
+
+// DefaultTimeout содержит предельное время выполнения одной операции с хранилищем по умолчанию.
+var DefaultTimeout = 5 * time.Second
+
+// BackupTimeout содержит предельное время создания резервной копии базы данных.
+var BackupTimeout = 5 * time.Minute
+
+// ErrConflict возвращается при изменении задачи, версия которой отличается от ожидаемой: задача уже
+// изменена другим запросом после того, как её прочитали.
+var ErrConflict = errors.New("задача изменена другим запросом")
+
+// ErrBusy возвращается, если база данных заблокирована другими соединениями дольше допустимого
+// ожидания. Операцию можно повторить позже.
+var ErrBusy = errors.New("база данных занята")
+
+// ErrInvalid соответствует ошибкам, вызванным самим запросом к хранилищу: не указан или не найден
+// идентификатор, недопустимы параметры выборки. Текст таких ошибок описывает конкретную причину,
+// а принадлежность к ним проверяется через errors.Is.
+var ErrInvalid = errors.New("недопустимый запрос к хранилищу")
+
+// invalidError - ошибка недопустимого запроса к хранилищу, соответствующая ErrInvalid.
+type invalidError string
+
+func (e invalidError) Error() string { return string(e) }
+
+func (e invalidError) Is(target error) bool { return target == ErrInvalid }
+
+// errInvalid возвращает ошибку недопустимого запроса с текстом по формату format и аргументам args.
+func errInvalid(format string, args ...any) error {
+	return invalidError(fmt.Sprintf(format, args...))
+}
+
+// ErrCanceled и ErrTimeout возвращаются функциями пакета, если операция с хранилищем прервана отменой
+// контекста или истечением срока её выполнения. Исходная ошибка контекста также доступна через errors.Is.
+var (
+	ErrCanceled = errors.New("операция с базой данных отменена")
+	ErrTimeout  = errors.New("превышено время выполнения операции с базой данных")
+)
+
+// getTimeout возвращает предельное время выполнения одной операции с хранилищем из переменной среды
+// окружения TODO_DB_TIMEOUT в формате time.ParseDuration (например, "3s"). Если она отсутствует или
+// недопустима, возвращает DefaultTimeout.
+func getTimeout() time.Duration {
+	if len(envTimeout) > 0 {
+		if d, err := time.ParseDuration(envTimeout); err == nil && d > 0 {
+			return d
+		}
+	}
+	return DefaultTimeout
+}
+
+// withTimeout возвращает контекст операции с хранилищем: ctx, ограниченный сроком getTimeout.
+func withTimeout(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithTimeout(ctx, getTimeout())
+}
+
+// storeErr заменяет ошибку err операции с контекстом ctx на ErrCanceled или ErrTimeout, если контекст
+// к этому моменту отменён или истёк: драйверы баз данных сообщают о прерванном запросе по-разному.
+// Ошибку блокировки базы данных другим соединением оборачивает в ErrBusy.
+func storeErr(ctx context.Context, err error) error {
+	if err == nil {
+		return nil
+	}
+	if ctx.Err() == nil {
+		if sqliteBusy(err) || postgresBusy(err) {
+			return fmt.Errorf("%w: %w", ErrBusy, err)
+		}
+		return err
+	}
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return fmt.Errorf("%w: %w", ErrTimeout, ctx.Err())
+	}
+	return fmt.Errorf("%w: %w", ErrCanceled, ctx.Err())
+}
+
+// NewStore возвращает хранилище задач по строке подключения dsn: MemoryDSN - хранилище в оперативной
+// памяти, "postgres://..." или "postgresql://..." - база данных PostgreSQL, иначе путь к файлу базы данных
+// SQLite. Схема базы данных не обновляется.
+func NewStore(dsn string) (TaskStore, error) {
+	switch {
+	case dsn == MemoryDSN:
+		return NewMemoryStore(), nil
+	case strings.HasPrefix(dsn, "postgres://"), strings.HasPrefix(dsn, "postgresql://"):
+		return NewPostgresStore(dsn)
+	}
+	return NewSQLiteStore(dsn)
+}
+
+// SetStore устанавливает хранилище задач s, с которым работают функции пакета. Позволяет, например,
+// подменить базу данных хранилищем в оперативной памяти.
+func SetStore(s TaskStore) {
+	store = s
+}
+
+// AddTask добавляет в хранилище задачу из task. Возвращает id добавленной задачи и возможную ошибку.
+func AddTask(ctx context.Context, task *Task) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.AddTask(ctx, task)
+	return res, storeErr(ctx, err)
+}
+
+// Tasks возвращает страницу задач хранилища, выбранных в соответствии с q. Описание поиска приведено
+// у SQLiteStore.Tasks.
+func Tasks(ctx context.Context, q TaskQuery) (*TaskPage, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.Tasks(ctx, q)
+	return res, storeErr(ctx, err)
+}
+
+// GetTask возвращает задачу хранилища с идентификатором id и возможную ошибку.
+func GetTask(ctx context.Context, id string) (*Task, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.GetTask(ctx, id)
+	return res, storeErr(ctx, err)
+}
+
+// UpdateTask обновляет поля задачи хранилища с идентификатором task.ID. Ненулевая task.Version - ожидаемая
+// версия задачи: если задача уже изменена, возвращает ErrConflict. При успешном обновлении task.Version
+// получает новую версию задачи. Возвращает возможную ошибку.
+func UpdateTask(ctx context.Context, task *Task) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.UpdateTask(ctx, task))
+}
+
+// DeleteTask перемещает задачу хранилища с идентификатором id в корзину. Задача в корзине не возвращается
+// GetTask и Tasks (кроме выборки с TaskQuery.Trash) и не может быть изменена. Возвращает возможную ошибку.
+func DeleteTask(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.DeleteTask(ctx, id))
+}
+
+// RestoreTask возвращает задачу с идентификатором id из корзины. Возвращает возможную ошибку.
+func RestoreTask(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.RestoreTask(ctx, id))
+}
+
+// PurgeTask окончательно удаляет задачу с идентификатором id из корзины вместе с её исключёнными датами.
+// Возвращает возможную ошибку.
+func PurgeTask(ctx context.Context, id string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.PurgeTask(ctx, id))
+}
+
+// PurgeTrash окончательно удаляет задачи, перемещённые в корзину раньше времени before.
+// Возвращает количество удалённых задач и возможную ошибку.
+func PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.PurgeTrash(ctx, before)
+	return res, storeErr(ctx, err)
+}
+
+// CompleteTask атомарно добавляет запись о выполнении c и либо переносит задачу на следующую дату,
+// обновляя её полями next, либо, если next равно nil, перемещает задачу с идентификатором c.TaskID
+// в корзину. Ненулевая version - ожидаемая версия задачи: если задача уже изменена, возвращает ErrConflict.
+// Если задачу не удалось обновить или удалить, запись не добавляется. Возвращает возможную ошибку.
+func CompleteTask(ctx context.Context, c *Completion, version int64, next *Task) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.CompleteTask(ctx, c, version, next))
+}
+
+// TaskCompletions возвращает историю выполнения задачи с идентификатором id и возможную ошибку.
+func TaskCompletions(ctx context.Context, id string) ([]Completion, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.TaskCompletions(ctx, id)
+	return res, storeErr(ctx, err)
+}
+
+// Completions возвращает записи о выполнении всех задач со временем выполнения в промежутке [from, to)
+// и возможную ошибку. Нулевое from или to не ограничивает выборку.
+func Completions(ctx context.Context, from, to time.Time) ([]Completion, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.Completions(ctx, from, to)
+	return res, storeErr(ctx, err)
+}
+
+// TaskRevisions возвращает историю изменений задачи с идентификатором id, упорядоченную по времени
+// изменения, и возможную ошибку.
+func TaskRevisions(ctx context.Context, id string) ([]Revision, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.TaskRevisions(ctx, id)
+	return res, storeErr(ctx, err)
+}
+
+// RevertTask возвращает поля задачи с идентификатором id к состоянию после ревизии revisionID, в том числе
+// восстанавливая задачу из корзины. Возвращает возможную ошибку.
+func RevertTask(ctx context.Context, id, revisionID string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.RevertTask(ctx, id, revisionID))
+}
+
+// Exclusions возвращает упорядоченный список исключённых дат задачи с идентификатором id и возможную ошибку.
+func Exclusions(ctx context.Context, id string) ([]string, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.Exclusions(ctx, id)
+	return res, storeErr(ctx, err)
+}
+
+// AddExclusion атомарно добавляет дату date в список исключённых дат задачи с идентификатором id и,
+// если next не равен nil, переносит задачу с исключённой даты полями next. Ненулевая version - ожидаемая
+// версия задачи.
+func AddExclusion(ctx context.Context, id, date string, version int64, next *Task) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.AddExclusion(ctx, id, date, version, next))
+}
+
+// DeleteExclusion удаляет дату date из списка исключённых дат задачи с идентификатором id.
+func DeleteExclusion(ctx context.Context, id, date string) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.DeleteExclusion(ctx, id, date))
+}
+
+// Holidays возвращает список праздничных дней хранилища, упорядоченный по дате, и возможную ошибку.
+func Holidays(ctx context.Context) ([]Holiday, error) {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	res, err := store.Holidays(ctx)
+	return res, storeErr(ctx, err)
+}
+
+// AddHolidays добавляет праздничные дни из list в хранилище, обновляя названия уже существующих дат.
+func AddHolidays(ctx context.Context, list []Holiday) error {
+	ctx, cancel := withTimeout(ctx)
+	defer cancel()
+	return storeErr(ctx, store.AddHolidays(ctx, list))
+}
+
+// Status возвращает состояние схемы базы данных хранилища. Для хранилища без миграций возвращает ошибку.
+func Status() (*MigrationStatus, error) {
+	m, ok := store.(Migrator)
+	if !ok {
+		return nil, fmt.Errorf("хранилище не поддерживает миграции")
+	}
+	return m.Status()
+}
+
+// Migrate применяет к базе данных хранилища недостающие миграции (см. migrator.Migrate). Хранилище без
+// миграций не изменяется.
+func Migrate(dryRun bool) ([]Migration, error) {
+	m, ok := store.(Migrator)
+	if !ok {
+		return nil, nil
+	}
+	return m.Migrate(dryRun)
+}
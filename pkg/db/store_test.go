@@ -0,0 +1,651 @@
+package db
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"go1f/pkg/filter"
+)
+
+// stores возвращает конструкторы проверяемых хранилищ. Хранилище PostgreSQL проверяется, если в
+// переменной среды окружения TODO_TEST_DSN задана строка подключения к тестовой базе данных, все
+// данные которой будут удалены. Команды для запуска с временным сервером в докере приведены в README.
+func stores(t *testing.T) map[string]func(t *testing.T) TaskStore {
+	res := map[string]func(t *testing.T) TaskStore{
+		"memory": func(t *testing.T) TaskStore { return NewMemoryStore() },
+		"sqlite": func(t *testing.T) TaskStore {
+			s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "scheduler.db"))
+			require.NoError(t, err)
+			return s
+		},
+	}
+	if dsn := os.Getenv("TODO_TEST_DSN"); len(dsn) > 0 {
+		res["postgres"] = func(t *testing.T) TaskStore {
+			s, err := NewPostgresStore(dsn)
+			require.NoError(t, err)
+			_, err = s.Migrate(false)
+			require.NoError(t, err)
+			_, err = s.db.Exec(`TRUNCATE scheduler, scheduler_exclusions, holidays, task_completions, task_revisions RESTART IDENTITY`)
+			require.NoError(t, err)
+			return s
+		}
+	} else {
+		t.Log("TODO_TEST_DSN не задана, хранилище PostgreSQL не проверяется")
+	}
+	return res
+}
+
+// TestStores проверяет одинаковое поведение всех реализаций TaskStore.
+func TestStores(t *testing.T) {
+	for name, newStore := range stores(t) {
+		t.Run(name, func(t *testing.T) {
+			s := newStore(t)
+			defer s.Close()
+			if m, ok := s.(Migrator); ok {
+				_, err := m.Migrate(false)
+				require.NoError(t, err)
+			}
+			t.Run("tasks", func(t *testing.T) { testTasks(t, s) })
+			t.Run("pages", func(t *testing.T) { testPages(t, s) })
+			t.Run("sort", func(t *testing.T) { testSort(t, s) })
+			t.Run("exclusions", func(t *testing.T) { testExclusions(t, s) })
+			t.Run("trash", func(t *testing.T) { testTrash(t, s) })
+			t.Run("completions", func(t *testing.T) { testCompletions(t, s) })
+			t.Run("revisions", func(t *testing.T) { testRevisions(t, s) })
+			t.Run("versions", func(t *testing.T) { testVersions(t, s) })
+			t.Run("holidays", func(t *testing.T) { testHolidays(t, s) })
+		})
+	}
+}
+
+func testTasks(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	page, err := s.Tasks(ctx, TaskQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.NotNil(t, page.Tasks)
+	assert.Empty(t, page.Tasks)
+	assert.Zero(t, page.Total)
+	assert.Empty(t, page.NextCursor)
+
+	tasks := []Task{
+		{Date: "20240301", Title: "Оплатить счета", Comment: "до обеда", Repeat: "m 1", Until: "20241231",
+			Remaining: 3, AfterDone: true, Time: "12:00", TZ: "Europe/Moscow"},
+		{Date: "20240201", Title: "Созвон", Time: "15:30"},
+		{Date: "20240201", Title: "Планёрка", Comment: "Отчёт за неделю", Time: "09:00"},
+	}
+	for i := range tasks {
+		id, err := s.AddTask(ctx, &tasks[i])
+		require.NoError(t, err)
+		assert.Positive(t, id)
+		got, err := s.GetTask(ctx, idString(id))
+		require.NoError(t, err)
+		assert.NotEmpty(t, got.CreatedAt)
+		assert.Equal(t, int64(1), got.Version)
+		tasks[i].ID, tasks[i].CreatedAt, tasks[i].Version = got.ID, got.CreatedAt, got.Version
+		assert.Equal(t, tasks[i], *got)
+	}
+
+	assert.Equal(t, []string{"Планёрка", "Созвон", "Оплатить счета"}, search(t, s, ""))
+	page, err = s.Tasks(ctx, TaskQuery{Limit: 2})
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, 2)
+	assert.Equal(t, 3, page.Total)
+	assert.NotEmpty(t, page.NextCursor)
+	for query, want := range map[string][]string{
+		"01.02.2024":                      {"Планёрка", "Созвон"},
+		"Отчёт":                           {"Планёрка"},
+		"отч":                             {"Планёрка"},
+		"title:отчёт":                     nil,
+		"comment:ОТЧЁТ":                   {"Планёрка"},
+		`comment:"до обеда"`:              {"Оплатить счета"},
+		"repeat:m":                        {"Оплатить счета"},
+		"repeat:*":                        {"Оплатить счета"},
+		"-repeat:*":                       {"Планёрка", "Созвон"},
+		"after:today":                     {"Оплатить счета"},
+		"before:tomorrow":                 {"Планёрка", "Созвон"},
+		"date:20240301 OR созвон":         {"Созвон", "Оплатить счета"},
+		"-(созвон OR планёрка)":           {"Оплатить счета"},
+		"after:yesterday before:20240301": {"Планёрка", "Созвон"},
+		"-comment:неделю":                 {"Созвон", "Оплатить счета"},
+		"title:\"100%\"":                  nil,
+	} {
+		assert.ElementsMatch(t, want, search(t, s, query), query)
+	}
+
+	update := tasks[1]
+	update.Date, update.Comment, update.TZ = "20240401", "перенесён", "Asia/Tokyo"
+	require.NoError(t, s.UpdateTask(ctx, &update))
+	assert.Equal(t, int64(2), update.Version)
+	got, err := s.GetTask(ctx, update.ID)
+	require.NoError(t, err)
+	assert.Equal(t, update, *got)
+	missing := update
+	missing.ID = "100000"
+	assert.ErrorIs(t, s.UpdateTask(ctx, &missing), ErrInvalid)
+
+	require.NoError(t, s.DeleteTask(ctx, update.ID))
+	_, err = s.GetTask(ctx, update.ID)
+	assert.ErrorIs(t, err, ErrInvalid)
+	_, err = s.GetTask(ctx, "")
+	assert.ErrorIs(t, err, ErrInvalid)
+	assert.Error(t, s.DeleteTask(ctx, ""))
+}
+
+func testPages(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	var want []string
+	for i := 0; i < 7; i++ {
+		task := Task{Date: "20240501", Title: "Страница " + strconv.Itoa(i)}
+		if i%3 == 0 {
+			task.Date = "20240502"
+		}
+		id, err := s.AddTask(ctx, &task)
+		require.NoError(t, err)
+		want = append(want, idString(id))
+	}
+	defer func() {
+		for _, id := range want {
+			require.NoError(t, s.DeleteTask(ctx, id))
+		}
+	}()
+	f, err := filter.Parse("страница", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err)
+	for _, node := range []filter.Node{filter.Term{Field: filter.FieldAfter, Value: "20240430"}, f} {
+		var got []string
+		q := TaskQuery{Filter: node, Limit: 3}
+		for pages := 0; ; pages++ {
+			require.Less(t, pages, 3)
+			page, err := s.Tasks(ctx, q)
+			require.NoError(t, err)
+			assert.Equal(t, len(want), page.Total)
+			for _, task := range page.Tasks {
+				got = append(got, task.ID)
+			}
+			if len(page.NextCursor) == 0 {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		assert.ElementsMatch(t, want, got, node.String())
+	}
+
+	_, err = s.Tasks(ctx, TaskQuery{Limit: 3, Cursor: "недопустимый"})
+	assert.Error(t, err)
+	_, err = s.Tasks(ctx, TaskQuery{})
+	assert.Error(t, err)
+}
+
+func testSort(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	tasks := []Task{
+		{Date: "20240602", Title: "Бюджет", Time: "10:00", CreatedAt: "2024-05-03T00:00:00Z"},
+		{Date: "20240601", Title: "Аренда", Repeat: "m 1", CreatedAt: "2024-05-01T00:00:00Z"},
+		{Date: "20240602", Title: "Встреча", Time: "09:00", CreatedAt: "2024-05-02T00:00:00Z"},
+		{Date: "20240603", Title: "Аренда", CreatedAt: "2024-05-02T00:00:00Z"},
+	}
+	var ids []string
+	for i := range tasks {
+		id, err := s.AddTask(ctx, &tasks[i])
+		require.NoError(t, err)
+		ids = append(ids, idString(id))
+	}
+	defer func() {
+		for _, id := range ids {
+			require.NoError(t, s.DeleteTask(ctx, id))
+		}
+	}()
+	f := filter.Term{Field: filter.FieldAfter, Value: "20240531"}
+	for order, want := range map[SortOrder][]int{
+		"":           {1, 2, 0, 3},
+		SortDate:     {1, 2, 0, 3},
+		SortDateDesc: {3, 0, 2, 1},
+		SortTitle:    {1, 3, 0, 2},
+		SortCreated:  {1, 2, 3, 0},
+	} {
+		var got []string
+		q := TaskQuery{Filter: f, Sort: order, Limit: 1}
+		for {
+			page, err := s.Tasks(ctx, q)
+			require.NoError(t, err, order)
+			for _, task := range page.Tasks {
+				got = append(got, task.ID)
+			}
+			if len(page.NextCursor) == 0 {
+				break
+			}
+			q.Cursor = page.NextCursor
+		}
+		var wantIDs []string
+		for _, i := range want {
+			wantIDs = append(wantIDs, ids[i])
+		}
+		assert.Equal(t, wantIDs, got, order)
+	}
+
+	page, err := s.Tasks(ctx, TaskQuery{Filter: f, Sort: SortTitle, Limit: 1})
+	require.NoError(t, err)
+	_, err = s.Tasks(ctx, TaskQuery{Filter: f, Sort: SortCreated, Limit: 1, Cursor: page.NextCursor})
+	assert.Error(t, err, "курсор другого порядка задач")
+	_, err = s.Tasks(ctx, TaskQuery{Sort: "size", Limit: 1})
+	assert.Error(t, err)
+}
+
+func testExclusions(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	id, err := s.AddTask(ctx, &Task{Date: "20240101", Title: "Зарядка", Repeat: "d 1"})
+	require.NoError(t, err)
+	taskID := idString(id)
+
+	require.NoError(t, s.AddExclusion(ctx, taskID, "20240105", 0, nil))
+	require.NoError(t, s.AddExclusion(ctx, taskID, "20240103", 0, nil))
+	require.NoError(t, s.AddExclusion(ctx, taskID, "20240105", 0, nil))
+	dates, err := s.Exclusions(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20240103", "20240105"}, dates)
+
+	task, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.ErrorIs(t, s.AddExclusion(ctx, taskID, "20240107", task.Version+1, nil), ErrConflict)
+	moved := *task
+	moved.Date = "20240102"
+	require.NoError(t, s.AddExclusion(ctx, taskID, "20240101", task.Version, &moved))
+	assert.ErrorIs(t, s.AddExclusion(ctx, taskID, "20240106", task.Version, nil), ErrConflict)
+	got, err := s.GetTask(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, "20240102", got.Date)
+	dates, err = s.Exclusions(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20240101", "20240103", "20240105"}, dates)
+
+	require.NoError(t, s.DeleteExclusion(ctx, taskID, "20240103"))
+	assert.Error(t, s.DeleteExclusion(ctx, taskID, "20240103"))
+	assert.Error(t, s.AddExclusion(ctx, "", "20240103", 0, nil))
+
+	require.NoError(t, s.DeleteTask(ctx, taskID))
+	dates, err = s.Exclusions(ctx, taskID)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"20240101", "20240105"}, dates, "исключённые даты сохраняются в корзине")
+	require.NoError(t, s.PurgeTask(ctx, taskID))
+	dates, err = s.Exclusions(ctx, taskID)
+	require.NoError(t, err)
+	assert.Empty(t, dates)
+}
+
+func testTrash(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	f := filter.Term{Field: filter.FieldDate, Value: "20240701"}
+	var ids []string
+	for _, title := range []string{"Черновик", "Архив", "Заметка"} {
+		id, err := s.AddTask(ctx, &Task{Date: "20240701", Title: title})
+		require.NoError(t, err)
+		ids = append(ids, idString(id))
+	}
+	for _, id := range ids[:2] {
+		require.NoError(t, s.DeleteTask(ctx, id))
+	}
+	assert.Error(t, s.DeleteTask(ctx, ids[0]), "задача уже в корзине")
+	assert.Error(t, s.DeleteTask(ctx, "100000"))
+	_, err := s.GetTask(ctx, ids[0])
+	assert.Error(t, err)
+	assert.Error(t, s.UpdateTask(ctx, &Task{ID: ids[0], Date: "20240701", Title: "Черновик"}))
+
+	page, err := s.Tasks(ctx, TaskQuery{Filter: f, Limit: 10})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Заметка"}, titles(page.Tasks))
+	page, err = s.Tasks(ctx, TaskQuery{Filter: f, Sort: SortTitle, Limit: 10, Trash: true})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"Архив", "Черновик"}, titles(page.Tasks))
+	assert.NotEmpty(t, page.Tasks[0].DeletedAt)
+
+	require.NoError(t, s.RestoreTask(ctx, ids[0]))
+	assert.Error(t, s.RestoreTask(ctx, ids[0]), "задача не в корзине")
+	got, err := s.GetTask(ctx, ids[0])
+	require.NoError(t, err)
+	assert.Equal(t, "Черновик", got.Title)
+	assert.Empty(t, got.DeletedAt)
+
+	assert.Error(t, s.PurgeTask(ctx, ids[2]), "задача не в корзине")
+	count, err := s.PurgeTrash(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	assert.Zero(t, count)
+	count, err = s.PurgeTrash(ctx, time.Now().Add(time.Hour))
+	require.NoError(t, err)
+	assert.Positive(t, count)
+	page, err = s.Tasks(ctx, TaskQuery{Limit: 10, Trash: true})
+	require.NoError(t, err)
+	assert.Empty(t, page.Tasks)
+	assert.Error(t, s.RestoreTask(ctx, ids[1]))
+
+	for _, id := range []string{ids[0], ids[2]} {
+		require.NoError(t, s.DeleteTask(ctx, id))
+		require.NoError(t, s.PurgeTask(ctx, id))
+	}
+}
+
+func testCompletions(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	task := Task{Date: "20240801", Title: "Полить цветы", Repeat: "d 3"}
+	id, err := s.AddTask(ctx, &task)
+	require.NoError(t, err)
+	task.ID = idString(id)
+
+	next := task
+	next.Date = "20240804"
+	require.NoError(t, s.CompleteTask(ctx, &Completion{TaskID: task.ID, Title: task.Title, Date: task.Date,
+		DoneAt: "2024-08-01T10:00:00Z", Note: "в срок"}, 0, &next))
+	got, err := s.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "20240804", got.Date)
+
+	require.NoError(t, s.CompleteTask(ctx, &Completion{TaskID: task.ID, Title: task.Title, Date: next.Date,
+		DoneAt: "2024-08-05T09:00:00Z"}, 0, nil))
+	_, err = s.GetTask(ctx, task.ID)
+	assert.Error(t, err, "выполненная задача перемещается в корзину")
+
+	missing := Completion{TaskID: "100000", Title: "Нет такой", Date: "20240801", DoneAt: "2024-08-02T00:00:00Z"}
+	assert.Error(t, s.CompleteTask(ctx, &missing, 0, nil))
+	assert.Error(t, s.CompleteTask(ctx, &Completion{TaskID: task.ID, DoneAt: "2024-08-02T00:00:00Z"}, 0, &next),
+		"задача в корзине не обновляется")
+
+	list, err := s.TaskCompletions(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 2)
+	assert.NotEmpty(t, list[0].ID)
+	assert.Equal(t, Completion{ID: list[0].ID, TaskID: task.ID, Title: "Полить цветы", Date: "20240801",
+		DoneAt: "2024-08-01T10:00:00Z", Note: "в срок"}, list[0])
+	assert.Equal(t, "20240804", list[1].Date)
+
+	day := func(d int) time.Time { return time.Date(2024, 8, d, 0, 0, 0, 0, time.UTC) }
+	for _, tc := range []struct {
+		from, to time.Time
+		want     int
+	}{
+		{day(1), day(2), 1},
+		{day(2), day(5), 0},
+		{day(1), day(6), 2},
+		{time.Time{}, day(2), 1},
+		{day(2), time.Time{}, 1},
+	} {
+		list, err := s.Completions(ctx, tc.from, tc.to)
+		require.NoError(t, err)
+		assert.Len(t, list, tc.want, "%v - %v", tc.from, tc.to)
+	}
+
+	require.NoError(t, s.PurgeTask(ctx, task.ID))
+	list, err = s.TaskCompletions(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 2, "история сохраняется после удаления задачи")
+}
+
+func testRevisions(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	task := Task{Date: "20240901", Title: "Отчёт", Comment: "черновик"}
+	id, err := s.AddTask(ctx, &task)
+	require.NoError(t, err)
+	task.ID = idString(id)
+
+	edited := task
+	edited.Title = "Квартальный отчёт"
+	edited.Date = "20240910"
+	require.NoError(t, s.UpdateTask(ctx, &edited))
+	require.NoError(t, s.UpdateTask(ctx, &edited))
+	require.NoError(t, s.DeleteTask(ctx, task.ID))
+
+	list, err := s.TaskRevisions(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 3, "изменение без новых значений полей не записывается")
+	assert.Equal(t, []string{RevisionCreate, RevisionUpdate, RevisionDelete},
+		[]string{list[0].Action, list[1].Action, list[2].Action})
+	assert.Nil(t, list[0].Before)
+	assert.Equal(t, "Отчёт", list[0].After.Title)
+	assert.Equal(t, []FieldChange{
+		{Field: "date", Before: "20240901", After: "20240910"},
+		{Field: "title", Before: "Отчёт", After: "Квартальный отчёт"},
+	}, list[1].Changes())
+	require.Len(t, list[2].Changes(), 1)
+	assert.Equal(t, "deleted_at", list[2].Changes()[0].Field)
+	for _, r := range list {
+		assert.NotEmpty(t, r.ID)
+		assert.Equal(t, task.ID, r.TaskID)
+		assert.NotEmpty(t, r.ChangedAt)
+	}
+
+	require.NoError(t, s.RevertTask(ctx, task.ID, list[0].ID))
+	got, err := s.GetTask(ctx, task.ID)
+	require.NoError(t, err, "задача возвращается из корзины")
+	assert.Equal(t, "Отчёт", got.Title)
+	assert.Equal(t, "20240901", got.Date)
+	assert.Equal(t, "черновик", got.Comment)
+
+	list, err = s.TaskRevisions(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 4)
+	assert.Equal(t, RevisionRevert, list[3].Action)
+
+	assert.Error(t, s.RevertTask(ctx, task.ID, "100000"))
+	other, err := s.AddTask(ctx, &Task{Date: "20240901", Title: "Другая"})
+	require.NoError(t, err)
+	assert.Error(t, s.RevertTask(ctx, idString(other), list[1].ID), "ревизия другой задачи")
+
+	require.NoError(t, s.DeleteTask(ctx, task.ID))
+	require.NoError(t, s.PurgeTask(ctx, task.ID))
+	list, err = s.TaskRevisions(ctx, task.ID)
+	require.NoError(t, err)
+	require.Len(t, list, 6, "история сохраняется после окончательного удаления")
+	assert.Equal(t, RevisionPurge, list[5].Action)
+	assert.Nil(t, list[5].After)
+	assert.Error(t, s.RevertTask(ctx, task.ID, list[1].ID))
+}
+
+func testVersions(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	id, err := s.AddTask(ctx, &Task{Date: "20241001", Title: "Тренировка", Repeat: "d 2"})
+	require.NoError(t, err)
+	task, err := s.GetTask(ctx, idString(id))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), task.Version)
+
+	stale := *task
+	task.Title = "Тренировка в зале"
+	require.NoError(t, s.UpdateTask(ctx, task))
+	assert.Equal(t, int64(2), task.Version)
+	stale.Comment = "устаревшая правка"
+	assert.ErrorIs(t, s.UpdateTask(ctx, &stale), ErrConflict)
+
+	// Два выполнения, прочитавшие одну версию задачи: второе отклоняется и не записывается в историю.
+	first, second := *task, *task
+	first.Date, second.Date = "20241003", "20241003"
+	done := Completion{TaskID: task.ID, Title: task.Title, Date: task.Date, DoneAt: "2024-10-01T08:00:00Z"}
+	require.NoError(t, s.CompleteTask(ctx, &done, task.Version, &first))
+	assert.ErrorIs(t, s.CompleteTask(ctx, &done, task.Version, &second), ErrConflict)
+	assert.ErrorIs(t, s.CompleteTask(ctx, &done, task.Version, nil), ErrConflict)
+	list, err := s.TaskCompletions(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Len(t, list, 1)
+
+	got, err := s.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, "20241003", got.Date)
+	assert.Equal(t, int64(3), got.Version)
+	assert.Empty(t, got.Comment)
+
+	got.Comment = "без проверки версии"
+	got.Version = 0
+	require.NoError(t, s.UpdateTask(ctx, got))
+	assert.Equal(t, int64(4), got.Version)
+	require.NoError(t, s.CompleteTask(ctx, &done, got.Version, nil))
+	require.NoError(t, s.RestoreTask(ctx, task.ID))
+	got, err = s.GetTask(ctx, task.ID)
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), got.Version)
+
+	missing := Task{ID: "100000", Date: "20241001", Title: "Нет такой", Version: 1}
+	err = s.UpdateTask(ctx, &missing)
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrConflict)
+}
+
+func testHolidays(t *testing.T, s TaskStore) {
+	ctx := context.Background()
+	require.NoError(t, s.AddHolidays(ctx, []Holiday{{"20240308", "8 марта"}, {"20240101", "Новый год"}}))
+	require.NoError(t, s.AddHolidays(ctx, []Holiday{{"20240101", "Новый год!"}}))
+	list, err := s.Holidays(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []Holiday{{"20240101", "Новый год!"}, {"20240308", "8 марта"}}, list)
+}
+
+// search возвращает заголовки задач хранилища s, найденных по запросу query. Текущей датой считается 01.02.2024.
+func search(t *testing.T, s TaskStore, query string) []string {
+	ctx := context.Background()
+	f, err := filter.Parse(query, time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC))
+	require.NoError(t, err, query)
+	page, err := s.Tasks(ctx, TaskQuery{Filter: f, Limit: 10})
+	require.NoError(t, err, query)
+	return titles(page.Tasks)
+}
+
+func idString(id int64) string {
+	return strconv.FormatInt(id, 10)
+}
+
+func titles(tasks []*Task) []string {
+	var res []string
+	for _, task := range tasks {
+		res = append(res, task.Title)
+	}
+	return res
+}
+
+// TestContext проверяет, что отменённый или истёкший контекст прерывает операцию с базой данных
+// и превращается в ErrCanceled или ErrTimeout.
+func TestContext(t *testing.T) {
+	s, err := NewSQLiteStore(filepath.Join(t.TempDir(), "scheduler.db"))
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.Migrate(false)
+	require.NoError(t, err)
+	SetStore(s)
+	defer SetStore(nil)
+
+	id, err := AddTask(context.Background(), &Task{Date: "20240101", Title: "Задача"})
+	require.NoError(t, err)
+
+	canceled, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = GetTask(canceled, idString(id))
+	assert.ErrorIs(t, err, ErrCanceled)
+	assert.ErrorIs(t, err, context.Canceled)
+
+	expired, cancel := context.WithDeadline(context.Background(), time.Now().Add(-time.Second))
+	defer cancel()
+	err = DeleteTask(expired, idString(id))
+	assert.ErrorIs(t, err, ErrTimeout)
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+	_, err = GetTask(context.Background(), idString(id))
+	assert.NoError(t, err, "прерванная операция не изменяет задачу")
+}
+
+// TestBackup проверяет резервное копирование базы данных SQLite, ротацию копий и проверку снимка.
+func TestBackup(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	s, err := NewSQLiteStore(filepath.Join(dir, "scheduler.db"))
+	require.NoError(t, err)
+	defer s.Close()
+	_, err = s.Migrate(false)
+	require.NoError(t, err)
+	_, err = s.AddTask(ctx, &Task{Date: "20240101", Title: "Сохранить"})
+	require.NoError(t, err)
+
+	path := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, s.Backup(ctx, path))
+	assert.Error(t, s.Backup(ctx, path), "существующий файл не перезаписывается")
+
+	version, err := CheckSnapshot(path)
+	require.NoError(t, err)
+	status, err := s.Status()
+	require.NoError(t, err)
+	assert.Equal(t, status.Latest, version)
+	snapshot, err := NewSQLiteStore(path)
+	require.NoError(t, err)
+	page, err := snapshot.Tasks(ctx, TaskQuery{Limit: 10})
+	require.NoError(t, err)
+	assert.Len(t, page.Tasks, 1)
+	snapshot.Close()
+
+	junk := filepath.Join(dir, "junk.db")
+	require.NoError(t, os.WriteFile(junk, []byte("не база данных"), 0o644))
+	for _, p := range []string{junk, filepath.Join(dir, "missing.db")} {
+		_, err := CheckSnapshot(p)
+		assert.Error(t, err, p)
+	}
+	_, err = os.Stat(filepath.Join(dir, "missing.db"))
+	assert.True(t, os.IsNotExist(err), "проверка не создаёт файл")
+
+	backups := filepath.Join(dir, "backups")
+	require.NoError(t, os.Mkdir(backups, 0o755))
+	for _, name := range []string{"scheduler-20240101-000000.db", "scheduler-20240102-000000.db",
+		"scheduler-20240103-000000.db", "notes.txt"} {
+		require.NoError(t, os.WriteFile(filepath.Join(backups, name), nil, 0o644))
+	}
+	require.NoError(t, rotateBackups(backups, 2))
+	files, err := os.ReadDir(backups)
+	require.NoError(t, err)
+	var names []string
+	for _, f := range files {
+		names = append(names, f.Name())
+	}
+	assert.Equal(t, []string{"notes.txt", "scheduler-20240102-000000.db", "scheduler-20240103-000000.db"}, names)
+}
+
+// TestRestoreSnapshot проверяет, что снимок не заменяет базу данных, занятую транзакцией другого
+// соединения или с оставшимся рядом файлом журнала.
+func TestRestoreSnapshot(t *testing.T) {
+	ctx := context.Background()
+	dir := t.TempDir()
+	dst := filepath.Join(dir, "scheduler.db")
+	s, err := NewSQLiteStore(dst)
+	require.NoError(t, err)
+	_, err = s.Migrate(false)
+	require.NoError(t, err)
+	_, err = s.AddTask(ctx, &Task{Date: "20240101", Title: "Из снимка"})
+	require.NoError(t, err)
+	src := filepath.Join(dir, "snapshot.db")
+	require.NoError(t, s.Backup(ctx, src))
+	_, err = s.AddTask(ctx, &Task{Date: "20240102", Title: "После снимка"})
+	require.NoError(t, err)
+
+	tx, err := s.db.Begin()
+	require.NoError(t, err)
+	assert.ErrorIs(t, restoreFile(src, dst), ErrBusy)
+	require.NoError(t, tx.Rollback())
+	require.NoError(t, s.Close())
+
+	journal := dst + "-journal"
+	require.NoError(t, os.WriteFile(journal, nil, 0o644))
+	assert.Error(t, restoreFile(src, dst), "оставшийся файл журнала")
+	require.NoError(t, os.Remove(journal))
+
+	count := func() int {
+		s, err := NewSQLiteStore(dst)
+		require.NoError(t, err)
+		defer s.Close()
+		page, err := s.Tasks(ctx, TaskQuery{Limit: 10})
+		require.NoError(t, err)
+		return len(page.Tasks)
+	}
+	assert.Equal(t, 2, count(), "база данных не заменена")
+
+	require.NoError(t, restoreFile(src, dst))
+	assert.Equal(t, 1, count())
+	_, err = os.Stat(dst + ".restore")
+	assert.True(t, os.IsNotExist(err), "временный файл удалён")
+}
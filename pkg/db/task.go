@@ -1,150 +1,295 @@
 package db
 
 import (
+	"context"
 	"database/sql"
-	"fmt"
+	"errors"
+	"strconv"
+	"strings"
 	"time"
+
+	"github.com/jmoiron/sqlx"
+
+	"go1f/pkg/filter"
 )
 
 // Task соответствует полям таблицы scheduler базы данных scheduler.db.
+// Until содержит дату окончания серии повторений (пустая строка - без ограничения),
+// Remaining - оставшееся количество повторений, включая текущее (0 - без ограничения).
+// AfterDone - режим повторения после выполнения: следующая дата отсчитывается от даты выполнения задачи,
+// а не от её текущей даты.
+// Time содержит необязательное время выполнения задачи в формате "15:04", TZ - часовой пояс IANA
+// ("Europe/Moscow"), в котором определяется текущая дата задачи (пустая строка - часовой пояс сервера).
+// CreatedAt содержит время создания задачи в UTC в формате RFC 3339, задаётся хранилищем при добавлении.
+// DeletedAt - время перемещения задачи в корзину в том же формате (пустая строка - действующая задача).
+// Version - версия задачи, увеличиваемая хранилищем при каждом её изменении (в json - строкой, как и ID).
+// Ненулевая Version при обновлении задачи - ожидаемая версия: если задача уже изменена, обновление
+// отклоняется с ErrConflict.
+// RepeatText - вычисляемое описание правила повторения на естественном языке, Snippet - фрагмент заголовка
+// или комментария с выделенными словами поиска; в базе данных не хранятся.
 type Task struct {
-	ID      string `json:"id"`
-	Date    string `json:"date"`
-	Title   string `json:"title"`
-	Comment string `json:"comment"`
-	Repeat  string `json:"repeat"`
+	ID         string `json:"id"`
+	Date       string `json:"date"`
+	Title      string `json:"title"`
+	Comment    string `json:"comment"`
+	Repeat     string `json:"repeat"`
+	Until      string `json:"until,omitempty"`
+	Time       string `json:"time,omitempty"`
+	TZ         string `json:"tz,omitempty"`
+	Remaining  int    `json:"remaining,omitempty"`
+	AfterDone  bool   `json:"after_done,omitempty"`
+	CreatedAt  string `json:"created_at,omitempty"`
+	DeletedAt  string `json:"deleted_at,omitempty"`
+	Version    int64  `json:"version,omitempty,string"`
+	RepeatText string `json:"repeat_text,omitempty"`
+	Snippet    string `json:"snippet,omitempty"`
+}
+
+// timestamp возвращает время t в UTC в формате RFC 3339, в котором хранятся CreatedAt и DeletedAt задач.
+func timestamp(t time.Time) string {
+	return t.UTC().Format(time.RFC3339)
+}
+
+// createdAt возвращает время создания добавляемой задачи task: task.CreatedAt, если оно задано,
+// иначе текущее время.
+func createdAt(task *Task) string {
+	if len(task.CreatedAt) > 0 {
+		return task.CreatedAt
+	}
+	return timestamp(time.Now())
 }
 
-// AddTask добавляет в таблицу scheduler базы данных scheduler.db задачу из task.
-// Возвращает id добавленной задачи и возможную ошибку.
-func AddTask(task *Task) (int64, error) {
+// AddTask добавляет в таблицу scheduler базы данных scheduler.db задачу из task и записывает добавление
+// в историю изменений задачи. Возвращает id добавленной задачи и возможную ошибку.
+func (s *SQLiteStore) AddTask(ctx context.Context, task *Task) (int64, error) {
 	var id int64
 
-	query := `INSERT INTO scheduler (date, title, comment, repeat) VALUES (:date, :title, :comment, :repeat)`
+	query := `INSERT INTO scheduler (date, title, comment, repeat, until, remaining, after_done, time, tz, created_at)
+	VALUES (:date, :title, :comment, :repeat, :until, :remaining, :after_done, :time, :tz, :created_at)`
 
-	res, err := db.Exec(query,
-		sql.Named("date", task.Date),
-		sql.Named("title", task.Title),
-		sql.Named("comment", task.Comment),
-		sql.Named("repeat", task.Repeat))
-	if err == nil {
-		id, err = res.LastInsertId()
-	}
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, query,
+			sql.Named("date", task.Date),
+			sql.Named("title", task.Title),
+			sql.Named("comment", task.Comment),
+			sql.Named("repeat", task.Repeat),
+			sql.Named("until", task.Until),
+			sql.Named("remaining", task.Remaining),
+			sql.Named("after_done", task.AfterDone),
+			sql.Named("time", task.Time),
+			sql.Named("tz", task.TZ),
+			sql.Named("created_at", createdAt(task)))
+		if err != nil {
+			return err
+		}
+		if id, err = res.LastInsertId(); err != nil {
+			return err
+		}
+		after, err := snapshot(ctx, tx, strconv.FormatInt(id, 10))
+		if err != nil {
+			return err
+		}
+		return addRevision(ctx, tx, strconv.FormatInt(id, 10), RevisionCreate, nil, after)
+	})
 	return id, err
 }
 
-// Tasks возвращает массив задач и возможную ошибку из таблицы scheduler базы данных scheduler.db.
-// В search передается строка для поиска.
-// Если строка пуста, возвращаются все задачи.
-// Если строка в формате "02.01.2006", возвращаются все задачи с указанной датой.
-// в остальных случаях возвращаются задачи, в полях title и/или comment которых присутствует эта строка.
-// Количество возвращаемых задач ограничено количеством, переданным в maxEntries.
-func Tasks(maxEntries int, search string) ([]*Task, error) {
-	var err error
-	var tasks []*Task
-	var query string
-	var date time.Time
-	if search == "" {
-		query = `SELECT id, date, title, comment, repeat FROM scheduler ORDER BY date LIMIT :limit`
-	} else {
-		date, err = time.Parse("02.01.2006", search)
-		if err == nil {
-			query = `SELECT id, date, title, comment, repeat FROM scheduler WHERE date = :date LIMIT :limit`
-
-		} else {
-			search = "%" + search + "%"
-			query = `SELECT id, date, title, comment, repeat FROM scheduler WHERE title LIKE :search OR comment LIKE :search ORDER BY date LIMIT :limit`
+// Tasks возвращает страницу задач и возможную ошибку из таблицы scheduler базы данных scheduler.db.
+// В q.Filter передаётся дерево условий запроса поиска (см. пакет filter), nil - все задачи. Условия на слова
+// проверяются по полнотекстовому индексу: слово запроса может быть началом слова задачи, регистр букв
+// не учитывается. Если запрос содержит условия на слова не под отрицанием, в Snippet возвращается фрагмент
+// текста с найденными словами, выделенными SnippetStart и SnippetEnd, а при пустом q.Sort задачи
+// упорядочиваются по релевантности (совпадения в заголовке весомее). Задачи с одинаковой релевантностью,
+// как и при поиске без слов, упорядочиваются в порядке q.Sort (по умолчанию по дате, времени и id),
+// поэтому страницы, выбранные по курсору, не пересекаются. Количество задач на странице ограничено q.Limit.
+// Запрос собирается из условий динамически, поэтому использует позиционные параметры.
+func (s *SQLiteStore) Tasks(ctx context.Context, q TaskQuery) (*TaskPage, error) {
+	page := &TaskPage{Tasks: make([]*Task, 0)}
+	if err := q.validate(); err != nil {
+		return page, err
+	}
+	where, args := taskWhere(q, sqliteText)
+	if err := s.db.GetContext(ctx, &page.Total, `SELECT count(*) FROM scheduler s WHERE `+where, args...); err != nil {
+		return page, err
+	}
 
+	keys := sortKeys(q.Sort)
+	from := `scheduler s`
+	snippet := `''`
+	if terms := filter.TextTerms(q.Filter); len(terms) > 0 {
+		var exprs []string
+		for _, term := range terms {
+			exprs = append(exprs, ftsTerm(term))
+		}
+		from = `scheduler s LEFT JOIN (
+			SELECT rowid, bm25(scheduler_fts, 10.0, 1.0) AS rank, snippet(scheduler_fts, -1, ?, ?, '…', ?) AS snippet
+			FROM scheduler_fts WHERE scheduler_fts MATCH ?
+		) f ON f.rowid = s.id`
+		args = append([]any{SnippetStart, SnippetEnd, SnippetTokens, strings.Join(exprs, " OR ")}, args...)
+		snippet = `coalesce(f.snippet, '')`
+		if q.relevance() {
+			keys = append([]sortKey{{expr: `f.rank IS NULL`}, {expr: `coalesce(f.rank, 0.0)`}}, keys...)
+		}
+	}
+	if len(q.Cursor) > 0 {
+		values, err := decodeCursor(q.Cursor, q.order(), len(keys))
+		if err != nil {
+			return page, err
 		}
+		cond, condArgs := keysetCondition(keys, values)
+		where += ` AND ` + cond
+		args = append(args, condArgs...)
 	}
-	rows, err := db.Query(query,
-		sql.Named("limit", maxEntries),
-		sql.Named("search", search),
-		sql.Named("date", date.Format(DateString)))
+	var exprs []string
+	for _, key := range keys {
+		exprs = append(exprs, key.expr)
+	}
+	query := `SELECT s.id, s.date, s.title, s.comment, s.repeat, s.until, s.remaining, s.after_done, s.time, s.tz, ` +
+		`s.created_at, s.deleted_at, s.version, ` + snippet + `, ` + strings.Join(exprs, `, `) + ` FROM ` + from + ` WHERE ` + where +
+		` ORDER BY ` + orderBy(keys) + ` LIMIT ?`
+	args = append(args, q.Limit+1)
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
 	if err != nil {
-		return tasks, err
+		return page, err
 	}
 	defer rows.Close()
+	var last []any
 	for rows.Next() {
+		if len(page.Tasks) == q.Limit {
+			page.NextCursor = encodeCursor(q.order(), last)
+			break
+		}
 		var task Task
-		err = rows.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+		values := make([]any, len(keys))
+		dest := []any{&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat, &task.Until, &task.Remaining,
+			&task.AfterDone, &task.Time, &task.TZ, &task.CreatedAt, &task.DeletedAt, &task.Version, &task.Snippet}
+		for i := range values {
+			dest = append(dest, &values[i])
+		}
+		err = rows.Scan(dest...)
 		if err != nil {
-			return tasks, err
+			return page, err
 		}
-		tasks = append(tasks, &task)
-	}
-	if err := rows.Err(); err != nil {
-		return tasks, err
+		page.Tasks = append(page.Tasks, &task)
+		last = values
 	}
-
-	if tasks == nil {
-		tasks = make([]*Task, 0)
-	}
-
-	return tasks, nil
+	return page, rows.Err()
 }
 
 // GetTask возвращает задачу и возможную ошибку из таблицы scheduler базы данных scheduler.db.
-// На вход получает id задачи.
-func GetTask(id string) (*Task, error) {
+// На вход получает id задачи. Задачи в корзине не возвращаются.
+func (s *SQLiteStore) GetTask(ctx context.Context, id string) (*Task, error) {
 	var task Task
 	if id == "" {
-		return &task, fmt.Errorf("не указан идентификатор")
+		return &task, errInvalid("не указан идентификатор")
 	}
 	var err error
 
-	query := `SELECT id, date, title, comment, repeat FROM scheduler WHERE id = :id`
+	query := `SELECT id, date, title, comment, repeat, until, remaining, after_done, time, tz, created_at, version
+	FROM scheduler WHERE id = :id AND deleted_at = ''`
 
-	row := db.QueryRow(query, sql.Named("id", id))
-	err = row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat)
+	row := s.db.QueryRowContext(ctx, query, sql.Named("id", id))
+	err = row.Scan(&task.ID, &task.Date, &task.Title, &task.Comment, &task.Repeat, &task.Until, &task.Remaining, &task.AfterDone, &task.Time, &task.TZ, &task.CreatedAt, &task.Version)
+	if errors.Is(err, sql.ErrNoRows) {
+		return &task, errInvalid("задача не найдена")
+	}
 	if err != nil {
-		return &task, fmt.Errorf("задача не найдена")
+		return &task, err
 	}
 	return &task, nil
 }
 
 // UpdateTask обновляет поля задачи таблицы scheduler базы данных scheduler.db полями задачи task.
-// Поиск экземпляра задачи в базе данных в соответствии с id задачи task. Возвращает возможную ошибку.
-func UpdateTask(task *Task) error {
+// Поиск экземпляра задачи в базе данных в соответствии с id задачи task, задачи в корзине не обновляются.
+// Если task.Version не равна нулю, задача обновляется, только если её версия не изменилась, иначе
+// возвращается ErrConflict. При успешном обновлении task.Version получает новую версию задачи.
+// Изменение записывается в историю изменений задачи. Возвращает возможную ошибку.
+func (s *SQLiteStore) UpdateTask(ctx context.Context, task *Task) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error { return updateTask(ctx, tx, task) })
+}
+
+// updateTask обновляет задачу task в транзакции tx и записывает изменение в историю.
+func updateTask(ctx context.Context, tx *sqlx.Tx, task *Task) error {
 	query := `UPDATE scheduler SET
 	date = :date,
 	title = :title,
 	comment = :comment,
-	repeat = :repeat
-	WHERE id = :id`
-
-	res, err := db.Exec(query,
-		sql.Named("id", task.ID),
-		sql.Named("date", task.Date),
-		sql.Named("title", task.Title),
-		sql.Named("comment", task.Comment),
-		sql.Named("repeat", task.Repeat))
-	if err != nil {
-		return err
-	}
+	repeat = :repeat,
+	until = :until,
+	remaining = :remaining,
+	after_done = :after_done,
+	time = :time,
+	tz = :tz,
+	version = version + 1
+	WHERE id = :id AND deleted_at = '' AND (:version = 0 OR version = :version)`
 
-	count, err := res.RowsAffected()
-	if err != nil {
-		return err
-	}
-	if count == 0 {
-		return fmt.Errorf("неверный id для обновления задачи")
-	}
-	return nil
+	return revise(ctx, tx, task.ID, RevisionUpdate, func() error {
+		res, err := tx.ExecContext(ctx, query,
+			sql.Named("id", task.ID),
+			sql.Named("date", task.Date),
+			sql.Named("title", task.Title),
+			sql.Named("comment", task.Comment),
+			sql.Named("repeat", task.Repeat),
+			sql.Named("until", task.Until),
+			sql.Named("remaining", task.Remaining),
+			sql.Named("after_done", task.AfterDone),
+			sql.Named("time", task.Time),
+			sql.Named("tz", task.TZ),
+			sql.Named("version", task.Version))
+		if err != nil {
+			return err
+		}
+		if err := checkVersion(ctx, tx, res, task.ID, task.Version, "неверный id для обновления задачи"); err != nil {
+			return err
+		}
+		return tx.GetContext(ctx, &task.Version, `SELECT version FROM scheduler WHERE id = :id`,
+			sql.Named("id", task.ID))
+	})
+}
+
+// DeleteTask перемещает задачу таблицы scheduler базы данных scheduler.db с указанным id в корзину.
+// Исключённые даты задачи сохраняются до её окончательного удаления. Возвращает ошибку, если задача
+// не найдена или уже находится в корзине. Удаление записывается в историю изменений задачи.
+func (s *SQLiteStore) DeleteTask(ctx context.Context, id string) error {
+	return s.inTx(ctx, func(tx *sqlx.Tx) error { return deleteTask(ctx, tx, id, 0) })
 }
 
-// DeleteTask удаляет задачу таблицы scheduler базы данных scheduler.db по указанному id.
-// Возвращает возможную ошибку
-func DeleteTask(id string) error {
+// deleteTask перемещает задачу с идентификатором id в корзину в транзакции tx и записывает удаление
+// в историю. Ненулевая version - ожидаемая версия задачи (см. UpdateTask).
+func deleteTask(ctx context.Context, tx *sqlx.Tx, id string, version int64) error {
 	if id == "" {
-		return fmt.Errorf("не указан идентификатор")
+		return errInvalid("не указан идентификатор")
 	}
 
-	query := `DELETE FROM scheduler WHERE id = :id`
+	query := `UPDATE scheduler SET deleted_at = :deleted_at, version = version + 1
+	WHERE id = :id AND deleted_at = '' AND (:version = 0 OR version = :version)`
 
-	_, err := db.Exec(query, sql.Named("id", id))
-	if err != nil {
+	return revise(ctx, tx, id, RevisionDelete, func() error {
+		res, err := tx.ExecContext(ctx, query, sql.Named("id", id), sql.Named("version", version),
+			sql.Named("deleted_at", timestamp(time.Now())))
+		if err != nil {
+			return err
+		}
+		return checkVersion(ctx, tx, res, id, version, "задача не найдена")
+	})
+}
+
+// checkVersion проверяет результат res изменения действующей задачи с идентификатором id и ожидаемой
+// версией version. Если ни одна строка не изменена, возвращает ErrConflict, когда задача существует
+// и не находится в корзине (значит, не совпала версия), иначе ошибку с текстом msg.
+func checkVersion(ctx context.Context, tx *sqlx.Tx, res sql.Result, id string, version int64, msg string) error {
+	err := checkAffected(res, msg)
+	if err == nil || version == 0 {
 		return err
 	}
-	return nil
+	var count int
+	query := `SELECT count(*) FROM scheduler WHERE id = :id AND deleted_at = ''`
+	if err := tx.GetContext(ctx, &count, query, sql.Named("id", id)); err != nil {
+		return err
+	}
+	if count > 0 {
+		return ErrConflict
+	}
+	return err
 }
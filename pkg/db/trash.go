@@ -0,0 +1,88 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// checkAffected возвращает ошибку с текстом msg, если запрос с результатом res не изменил ни одной строки.
+func checkAffected(res sql.Result, msg string) error {
+	count, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if count == 0 {
+		return errInvalid("%s", msg)
+	}
+	return nil
+}
+
+// RestoreTask возвращает задачу с идентификатором id из корзины в число действующих задач и записывает
+// возврат в историю изменений задачи. Возвращает ошибку, если задача в корзине не найдена.
+func (s *SQLiteStore) RestoreTask(ctx context.Context, id string) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	query := `UPDATE scheduler SET deleted_at = '', version = version + 1 WHERE id = :id AND deleted_at <> ''`
+
+	return s.inTx(ctx, func(tx *sqlx.Tx) error {
+		return revise(ctx, tx, id, RevisionRestore, func() error {
+			res, err := tx.ExecContext(ctx, query, sql.Named("id", id))
+			if err != nil {
+				return err
+			}
+			return checkAffected(res, "задача в корзине не найдена")
+		})
+	})
+}
+
+// PurgeTask окончательно удаляет задачу с идентификатором id из корзины вместе с её исключёнными
+// датами и записывает удаление в историю изменений задачи. Возвращает ошибку, если задача в корзине
+// не найдена.
+func (s *SQLiteStore) PurgeTask(ctx context.Context, id string) error {
+	if id == "" {
+		return errInvalid("не указан идентификатор")
+	}
+	return s.inTx(ctx, func(tx *sqlx.Tx) error { return purgeTask(ctx, tx, id) })
+}
+
+// purgeTask окончательно удаляет задачу с идентификатором id из корзины в транзакции tx.
+func purgeTask(ctx context.Context, tx *sqlx.Tx, id string) error {
+	query := `DELETE FROM scheduler WHERE id = :id AND deleted_at <> ''`
+
+	return revise(ctx, tx, id, RevisionPurge, func() error {
+		res, err := tx.ExecContext(ctx, query, sql.Named("id", id))
+		if err != nil {
+			return err
+		}
+		return checkAffected(res, "задача в корзине не найдена")
+	})
+}
+
+// PurgeTrash окончательно удаляет задачи, перемещённые в корзину раньше времени before, записывая
+// удаление каждой задачи в историю. Возвращает количество удалённых задач и возможную ошибку.
+func (s *SQLiteStore) PurgeTrash(ctx context.Context, before time.Time) (int64, error) {
+	var count int64
+	query := `SELECT id FROM scheduler WHERE deleted_at <> '' AND deleted_at < :before`
+
+	err := s.inTx(ctx, func(tx *sqlx.Tx) error {
+		var ids []string
+		if err := tx.SelectContext(ctx, &ids, query, sql.Named("before", timestamp(before))); err != nil {
+			return err
+		}
+		for _, id := range ids {
+			if err := purgeTask(ctx, tx, id); err != nil {
+				return err
+			}
+		}
+		count = int64(len(ids))
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return count, nil
+}
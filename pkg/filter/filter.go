@@ -0,0 +1,142 @@
+// Пакет filter реализует язык запросов поиска задач: разбор строки запроса вида
+// `title:отчёт repeat:w before:01.12.2026 after:today -comment:draft` в дерево условий (AST),
+// которое хранилища задач компилируют в запросы к базе данных.
+package filter
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Field - поле задачи, к которому относится условие.
+type Field string
+
+const (
+	FieldText    Field = ""        // Слова в заголовке или комментарии (условие без имени поля)
+	FieldTitle   Field = "title"   // Слова в заголовке
+	FieldComment Field = "comment" // Слова в комментарии
+	FieldRepeat  Field = "repeat"  // Начало правила повторения или "*" - любое правило
+	FieldDate    Field = "date"    // Дата задачи
+	FieldBefore  Field = "before"  // Дата задачи раньше указанной
+	FieldAfter   Field = "after"   // Дата задачи позже указанной
+)
+
+// AnyRepeat - значение условия repeat, которому соответствует любое правило повторения.
+const AnyRepeat = "*"
+
+// Node - узел дерева условий запроса: Term, And, Or или Not.
+type Node interface {
+	String() string
+}
+
+// Term - условие на поле Field. Для полей дат Value содержит дату в формате "20060102".
+type Term struct {
+	Field Field
+	Value string
+}
+
+// And - условие, выполняющееся при выполнении всех входящих в него условий.
+type And []Node
+
+// Or - условие, выполняющееся при выполнении хотя бы одного из входящих в него условий.
+type Or []Node
+
+// Not - отрицание условия Node.
+type Not struct {
+	Node Node
+}
+
+// String возвращает условие в виде "поле:значение" (значение в кавычках, если содержит пробелы).
+func (t Term) String() string {
+	value := t.Value
+	if strings.ContainsAny(value, " ()\"") || len(value) == 0 {
+		value = `"` + strings.ReplaceAll(value, `"`, `\"`) + `"`
+	}
+	if t.Field == FieldText {
+		return value
+	}
+	return string(t.Field) + ":" + value
+}
+
+// String возвращает условия через пробел в скобках.
+func (a And) String() string {
+	return "(" + join(a, " ") + ")"
+}
+
+// String возвращает условия через " OR " в скобках.
+func (o Or) String() string {
+	return "(" + join(o, " OR ") + ")"
+}
+
+// String возвращает условие с префиксом "-".
+func (n Not) String() string {
+	return "-" + n.Node.String()
+}
+
+// join объединяет строковые представления условий nodes через sep.
+func join(nodes []Node, sep string) string {
+	var parts []string
+	for _, node := range nodes {
+		parts = append(parts, node.String())
+	}
+	return strings.Join(parts, sep)
+}
+
+// TextTerms возвращает условия на слова (FieldText, FieldTitle, FieldComment), которые должны
+// присутствовать в найденных задачах, то есть не находятся под отрицанием. По ним хранилища
+// упорядочивают задачи по релевантности.
+func TextTerms(node Node) []Term {
+	var res []Term
+	var walk func(node Node)
+	walk = func(node Node) {
+		switch n := node.(type) {
+		case Term:
+			if n.IsText() {
+				res = append(res, n)
+			}
+		case And:
+			for _, c := range n {
+				walk(c)
+			}
+		case Or:
+			for _, c := range n {
+				walk(c)
+			}
+		}
+	}
+	if node != nil {
+		walk(node)
+	}
+	return res
+}
+
+// IsText проверяет, является ли условие условием на слова заголовка или комментария.
+func (t Term) IsText() bool {
+	return t.Field == FieldText || t.Field == FieldTitle || t.Field == FieldComment
+}
+
+// Operator описывает оператор языка запросов для справки.
+type Operator struct {
+	Syntax      string `json:"syntax"`
+	Description string `json:"description"`
+	Example     string `json:"example"`
+}
+
+// Operators содержит описание всех операторов языка запросов.
+var Operators = []Operator{
+	{"слово", "задачи, в заголовке или комментарии которых есть слово, начинающееся с указанного (без учёта регистра)", "отчёт"},
+	{`"несколько слов"`, "значение в кавычках может содержать пробелы; все слова должны присутствовать", `"годовой отчёт"`},
+	{"title:слово", "слово в заголовке задачи", "title:отчёт"},
+	{"comment:слово", "слово в комментарии задачи", "comment:черновик"},
+	{"repeat:начало", fmt.Sprintf("правило повторения начинается с указанного текста; repeat:%s - любое правило", AnyRepeat), "repeat:w"},
+	{"date:дата", "дата задачи совпадает с указанной", "date:01.12.2026"},
+	{"before:дата", "дата задачи раньше указанной", "before:01.12.2026"},
+	{"after:дата", "дата задачи позже указанной", "after:today"},
+	{"дата", "дата без имени поля равнозначна date:дата", "01.12.2026"},
+	{"-условие", "отрицание условия; дефис, за которым не следует условие, считается обычным текстом", "-comment:черновик"},
+	{"условие условие", "выполняются оба условия", "title:отчёт repeat:m"},
+	{"условие OR условие", "выполняется хотя бы одно из условий", "repeat:w OR repeat:d"},
+	{"(условия)", "группировка условий", "-(repeat:w OR repeat:d)"},
+	{"текст:", "условие с неизвестным полем, пустым или недопустимым значением ищется как обычные слова", "Время: 10"},
+	{"даты", `даты записываются как "02.01.2006", "20060102", today, tomorrow или yesterday`, "before:tomorrow"},
+}
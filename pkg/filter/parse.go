@@ -0,0 +1,309 @@
+package filter
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// DateFormat содержит формат дат условий в дереве запроса.
+const DateFormat = "20060102"
+
+// OrKeyword - ключевое слово, разделяющее альтернативные условия.
+const OrKeyword = "OR"
+
+// ParseError описывает ошибку разбора строки запроса.
+type ParseError struct {
+	Query string // Исходная строка запроса
+	Pos   int    // Позиция символа в строке, с которого начинается ошибка (с 1), либо 0 для запроса целиком
+	Msg   string // Описание ошибки
+}
+
+// Error возвращает описание ошибки разбора с указанием позиции.
+func (e *ParseError) Error() string {
+	if e.Pos > 0 {
+		return fmt.Sprintf("запрос '%s', позиция %d: %s", e.Query, e.Pos, e.Msg)
+	}
+	return fmt.Sprintf("запрос '%s': %s", e.Query, e.Msg)
+}
+
+// tokenKind - вид лексемы запроса.
+type tokenKind int
+
+const (
+	tokenWord tokenKind = iota
+	tokenOr
+	tokenNot
+	tokenOpen
+	tokenClose
+)
+
+// token - лексема запроса: условие "поле:значение" (field может быть пустым), OR, "-" или скобка.
+// text - исходный текст условия, pos - позиция начала лексемы в символах (с 1), valuePos - позиция
+// значения условия.
+type token struct {
+	kind     tokenKind
+	field    string
+	value    string
+	text     string
+	pos      int
+	valuePos int
+}
+
+// parser хранит состояние разбора строки запроса.
+type parser struct {
+	query  string
+	tokens []token
+	i      int
+	today  time.Time
+}
+
+// Parse разбирает строку запроса s в дерево условий. Даты today, tomorrow и yesterday отсчитываются
+// от даты today. Обычный текст из строки поиска не должен приводить к ошибке, поэтому условие
+// с неизвестным полем, пустым или недопустимым значением ищется как слова (см. term), а слова без букв
+// и цифр пропускаются. Для пустой строки возвращает nil: запросу соответствуют все задачи. Ошибку
+// *ParseError возвращает только при нарушении структуры запроса: незакрытой скобки или кавычки, лишней
+// закрывающей скобки, пустых скобок или OR без условия.
+func Parse(s string, today time.Time) (Node, error) {
+	p := &parser{query: s, today: today}
+	if err := p.tokenize(); err != nil {
+		return nil, err
+	}
+	if len(p.tokens) == 0 {
+		return nil, nil
+	}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.i < len(p.tokens) {
+		return nil, p.errorAt(p.tokens[p.i].pos, "лишняя закрывающая скобка")
+	}
+	return node, nil
+}
+
+// errorAt возвращает ошибку разбора в позиции pos.
+func (p *parser) errorAt(pos int, format string, args ...any) error {
+	return &ParseError{Query: p.query, Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// tokenize разбивает строку запроса на лексемы.
+func (p *parser) tokenize() error {
+	runes := []rune(p.query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			p.tokens = append(p.tokens, token{kind: tokenOpen, pos: i + 1})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{kind: tokenClose, pos: i + 1})
+			i++
+		case r == '-' && i+1 < len(runes) && (unicode.IsLetter(runes[i+1]) || strings.ContainsRune(`("`, runes[i+1])):
+			p.tokens = append(p.tokens, token{kind: tokenNot, pos: i + 1})
+			i++
+		default:
+			tok, next, err := p.word(runes, i)
+			if err != nil {
+				return err
+			}
+			i = next
+			if tok.kind == tokenWord && len(tok.field) == 0 && !hasWords(tok.value) {
+				// Знаки препинания без слов, например тире в тексте, условием не являются.
+				if n := len(p.tokens); n > 0 && p.tokens[n-1].kind == tokenNot {
+					p.tokens = p.tokens[:n-1]
+				}
+				continue
+			}
+			p.tokens = append(p.tokens, tok)
+		}
+	}
+	return nil
+}
+
+// word считывает условие, начинающееся с позиции start: необязательное имя поля из букв с двоеточием
+// и значение - слово до пробела или скобки, либо текст в кавычках. Если поле неизвестно или значение
+// пустое ("Время: 10"), весь текст условия считается словами без имени поля. Возвращает лексему
+// и позицию, следующую за ней.
+func (p *parser) word(runes []rune, start int) (token, int, error) {
+	tok := token{kind: tokenWord, pos: start + 1, valuePos: start + 1}
+	i := start
+	for i < len(runes) && unicode.IsLetter(runes[i]) {
+		i++
+	}
+	if i > start && i < len(runes) && runes[i] == ':' {
+		tok.field = strings.ToLower(string(runes[start:i]))
+		i++
+		tok.valuePos = i + 1
+	} else {
+		i = start
+	}
+	if i < len(runes) && runes[i] == '"' {
+		var value strings.Builder
+		for i++; i < len(runes) && runes[i] != '"'; i++ {
+			if runes[i] == '\\' && i+1 < len(runes) {
+				i++
+			}
+			value.WriteRune(runes[i])
+		}
+		if i == len(runes) {
+			return tok, i, p.errorAt(tok.valuePos, "не закрыта кавычка")
+		}
+		tok.value = value.String()
+		i++
+	} else {
+		from := i
+		for i < len(runes) && !unicode.IsSpace(runes[i]) && runes[i] != '(' && runes[i] != ')' {
+			i++
+		}
+		tok.value = string(runes[from:i])
+	}
+	tok.text = string(runes[start:i])
+	if len(tok.field) > 0 && (!knownField(Field(tok.field)) || len(tok.value) == 0) {
+		tok.field, tok.value, tok.valuePos = "", tok.text, tok.pos
+	}
+	if len(tok.field) == 0 && tok.text == OrKeyword {
+		tok.kind = tokenOr
+	}
+	return tok, i, nil
+}
+
+// knownField проверяет, является ли field именем поля условия.
+func knownField(field Field) bool {
+	switch field {
+	case FieldTitle, FieldComment, FieldRepeat, FieldDate, FieldBefore, FieldAfter:
+		return true
+	}
+	return false
+}
+
+// hasWords проверяет, содержит ли значение s буквы или цифры.
+func hasWords(s string) bool {
+	return strings.ContainsFunc(s, func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) })
+}
+
+// peek возвращает текущую лексему или nil, если лексемы закончились.
+func (p *parser) peek() *token {
+	if p.i < len(p.tokens) {
+		return &p.tokens[p.i]
+	}
+	return nil
+}
+
+// parseOr разбирает условия, разделённые OR.
+func (p *parser) parseOr() (Node, error) {
+	var nodes Or
+	for {
+		node, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+		tok := p.peek()
+		if tok == nil || tok.kind != tokenOr {
+			break
+		}
+		p.i++
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+// parseAnd разбирает следующие друг за другом условия до OR, закрывающей скобки или конца запроса.
+func (p *parser) parseAnd() (Node, error) {
+	var nodes And
+	for tok := p.peek(); tok != nil && tok.kind != tokenOr && tok.kind != tokenClose; tok = p.peek() {
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		nodes = append(nodes, node)
+	}
+	if len(nodes) == 0 {
+		pos := utf8.RuneCountInString(p.query)
+		if tok := p.peek(); tok != nil {
+			pos = tok.pos
+		}
+		return nil, p.errorAt(pos, "ожидается условие")
+	}
+	if len(nodes) == 1 {
+		return nodes[0], nil
+	}
+	return nodes, nil
+}
+
+// parseUnary разбирает условие с необязательным отрицанием, условие в скобках или отдельное условие.
+func (p *parser) parseUnary() (Node, error) {
+	tok := p.peek()
+	p.i++
+	switch tok.kind {
+	case tokenNot:
+		if next := p.peek(); next == nil || next.kind == tokenOr || next.kind == tokenClose {
+			return nil, p.errorAt(tok.pos, "отрицание без условия")
+		}
+		node, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return Not{Node: node}, nil
+	case tokenOpen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if next := p.peek(); next == nil || next.kind != tokenClose {
+			return nil, p.errorAt(tok.pos, "не закрыта скобка")
+		}
+		p.i++
+		return node, nil
+	}
+	return p.term(tok), nil
+}
+
+// term возвращает условие лексемы tok. Условие на слова без слов в значении (title:"...") и условие
+// на дату с недопустимой датой (before:32.01.2026) ищутся как слова исходного текста лексемы.
+func (p *parser) term(tok *token) Node {
+	field := Field(tok.field)
+	switch field {
+	case FieldText:
+		if date, err := time.Parse("02.01.2006", tok.value); err == nil {
+			return Term{Field: FieldDate, Value: date.Format(DateFormat)}
+		}
+	case FieldTitle, FieldComment:
+		if !hasWords(tok.value) {
+			return Term{Field: FieldText, Value: tok.text}
+		}
+	case FieldDate, FieldBefore, FieldAfter:
+		date, err := ParseDate(tok.value, p.today)
+		if err != nil {
+			return Term{Field: FieldText, Value: tok.text}
+		}
+		return Term{Field: field, Value: date.Format(DateFormat)}
+	}
+	return Term{Field: field, Value: tok.value}
+}
+
+// ParseDate разбирает дату условия: "02.01.2006", "20060102", today, tomorrow или yesterday. Даты today,
+// tomorrow и yesterday отсчитываются от даты today.
+func ParseDate(s string, today time.Time) (time.Time, error) {
+	switch strings.ToLower(s) {
+	case "today":
+		return today, nil
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), nil
+	case "yesterday":
+		return today.AddDate(0, 0, -1), nil
+	}
+	for _, layout := range []string{"02.01.2006", DateFormat} {
+		if t, err := time.Parse(layout, s); err == nil {
+			return t, nil
+		}
+	}
+	return time.Time{}, fmt.Errorf("недопустимая дата '%s' (ожидается 02.01.2006, 20060102, today, tomorrow или yesterday)", s)
+}
@@ -0,0 +1,60 @@
+package filter
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// today - текущая дата разбора запросов в тестах.
+var today = time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)
+
+func TestParse(t *testing.T) {
+	for query, want := range map[string]string{
+		"отчёт":                       "отчёт",
+		"title:отчёт repeat:w":        "(title:отчёт repeat:w)",
+		`comment:"до обеда"`:          `comment:"до обеда"`,
+		"-comment:черновик":           "-comment:черновик",
+		"-(repeat:w OR repeat:d)":     "-(repeat:w OR repeat:d)",
+		"after:today before:20240301": "(after:20240201 before:20240301)",
+		"01.02.2024":                  "date:20240201",
+		"ток-шоу":                     "ток-шоу",
+		"Время: 10":                   "(Время: 10)",
+		"size:5":                      "size:5",
+		"title:":                      "title:",
+		"title: отчёт":                "(title: отчёт)",
+		`title:"!!!"`:                 `"title:\"!!!\""`,
+		"before:32.01.2026":           "before:32.01.2026",
+		"-5 градусов":                 "(-5 градусов)",
+		"купить - молоко":             "(купить молоко)",
+		"купить — молоко":             "(купить молоко)",
+		"- купить":                    "купить",
+		"отчёт -":                     "отчёт",
+		`-"!!!" отчёт`:                "отчёт",
+		"-":                           "",
+		"!!!":                         "",
+	} {
+		node, err := Parse(query, today)
+		if !assert.NoError(t, err, query) {
+			continue
+		}
+		if len(want) == 0 {
+			assert.Nil(t, node, query)
+			continue
+		}
+		if assert.NotNil(t, node, query) {
+			assert.Equal(t, want, node.String(), query)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, query := range []string{"(отчёт", "отчёт)", `title:"отчёт`, `"отчёт`, "отчёт OR", "OR отчёт", "()",
+		"-("} {
+		_, err := Parse(query, today)
+		var parseErr *ParseError
+		assert.True(t, errors.As(err, &parseErr), "ожидается ошибка для запроса '%s'", query)
+	}
+}
@@ -0,0 +1,208 @@
+package repeat
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// atoi конвертирует строку s в целое число.
+func atoi(s string) (int, error) {
+	return strconv.Atoi(s)
+}
+
+// checkStep проверяет шаг повторения правил 'd' и 'b'.
+func checkStep(v int) error {
+	if v < 1 || v > MaxStep {
+		return fmt.Errorf("недопустимый шаг повторения: %d!(допускается от 1 до %d)", v, MaxStep)
+	}
+	return nil
+}
+
+// checkWeekday проверяет номер дня недели (пн-1...вс-7).
+func checkWeekday(v int) error {
+	if v < 1 || v > DaysInWeek {
+		return fmt.Errorf("недопустимое число дня недели: %d!(допускается от 1 до 7)", v)
+	}
+	return nil
+}
+
+// checkMonthDay проверяет номер дня месяца правила 'm' (1...31, -1 - последний, -2 - предпоследний).
+func checkMonthDay(v int) error {
+	if !((v >= 1 && v <= 31) || v == -1 || v == -2) {
+		return fmt.Errorf("недопустимое число дня месяца: %d!(допускается от 1 до 31, -1, -2)", v)
+	}
+	return nil
+}
+
+// checkMonth проверяет номер месяца.
+func checkMonth(v int) error {
+	if v < 1 || v > 12 {
+		return fmt.Errorf("недопустимое число месяца: %d!(допускается от 1 до 12)", v)
+	}
+	return nil
+}
+
+// checkOrdinal проверяет порядковый номер дня недели в месяце правила 'n' (1...5, -1 - последний).
+func checkOrdinal(v int) error {
+	if !((v >= 1 && v <= MaxOrdinal) || v == -1) {
+		return fmt.Errorf("недопустимый порядковый номер дня недели: %d!(допускается от 1 до %d, -1)", v, MaxOrdinal)
+	}
+	return nil
+}
+
+// checkInterval проверяет интервал повторения правил 'w', 'm', 'n' и 'y'.
+func checkInterval(v int) error {
+	if v < 1 || v > MaxInterval {
+		return fmt.Errorf("недопустимый интервал повторения: %d!(допускается от 1 до %d)", v, MaxInterval)
+	}
+	return nil
+}
+
+// checkList проверяет непустой список values функцией check. Название списка name используется
+// в тексте ошибки.
+func checkList(name string, values []int, check func(int) error) error {
+	if len(values) == 0 {
+		return fmt.Errorf("не указаны %s", name)
+	}
+	for _, v := range values {
+		if err := check(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkOptionalList проверяет список values функцией check. Пустой список допустим.
+func checkOptionalList(values []int, check func(int) error) error {
+	for _, v := range values {
+		if err := check(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// intervalOrOne возвращает интервал повторения, считая нулевое значение равным 1.
+func intervalOrOne(interval int) int {
+	if interval == 0 {
+		return 1
+	}
+	return interval
+}
+
+// checkIntervalOrZero проверяет интервал повторения, допуская нулевое значение (равное 1).
+func checkIntervalOrZero(interval int) error {
+	if interval == 0 {
+		return nil
+	}
+	return checkInterval(interval)
+}
+
+// formatList возвращает канонический вид списка чисел: без повторов, положительные числа по
+// возрастанию, затем отрицательные по убыванию ("1,15,-1,-2").
+func formatList(values []int) string {
+	sorted := append([]int(nil), values...)
+	sort.Slice(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if (a > 0) != (b > 0) {
+			return a > 0
+		}
+		if a > 0 {
+			return a < b
+		}
+		return a > b
+	})
+	var res []string
+	for i, v := range sorted {
+		if i == 0 || v != sorted[i-1] {
+			res = append(res, strconv.Itoa(v))
+		}
+	}
+	return strings.Join(res, ",")
+}
+
+// formatInterval возвращает группу интервала для канонического вида правила, либо пустую строку
+// для интервала 1.
+func formatInterval(interval int) string {
+	if intervalOrOne(interval) == 1 {
+		return ""
+	}
+	return " " + IntervalPrefix + strconv.Itoa(interval)
+}
+
+// contains проверяет, присутствует ли число v в слайсе list.
+func contains(list []int, v int) bool {
+	for _, l := range list {
+		if l == v {
+			return true
+		}
+	}
+	return false
+}
+
+// midnight возвращает начало дня даты t.
+func midnight(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+}
+
+// anchor возвращает дату начала серии start, либо дату after, если начало серии не задано.
+func anchor(start, after time.Time) time.Time {
+	if start.IsZero() {
+		return after
+	}
+	return start
+}
+
+// window возвращает дату начала серии и дату, после которой ищется следующая дата правила: начало серии
+// start (либо after, если оно не задано) и более позднюю из дат after и начала серии.
+func window(start, after time.Time) (time.Time, time.Time) {
+	after = midnight(after)
+	start = anchor(start, after)
+	return start, later(start, after)
+}
+
+// later возвращает более позднюю из дат a и b.
+func later(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+// Weekday возвращает номер дня недели даты t в нумерации правил задач (пн-1...вс-7).
+func Weekday(t time.Time) int {
+	if t.Weekday() == time.Sunday {
+		return DaysInWeek
+	}
+	return int(t.Weekday())
+}
+
+// startOfWeek возвращает понедельник недели, в которую попадает дата t.
+func startOfWeek(t time.Time) time.Time {
+	return t.AddDate(0, 0, 1-Weekday(t))
+}
+
+// startOfMonth возвращает первое число месяца, в который попадает дата t.
+func startOfMonth(t time.Time) time.Time {
+	return time.Date(t.Year(), t.Month(), 1, 0, 0, 0, 0, t.Location())
+}
+
+// daysIn возвращает количество дней в месяце, в который попадает дата t.
+func daysIn(t time.Time) int {
+	return time.Date(t.Year(), t.Month()+1, 0, 0, 0, 0, 0, t.Location()).Day()
+}
+
+// daysBetween возвращает количество календарных дней от даты from до даты to.
+func daysBetween(from, to time.Time) int {
+	f := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, time.UTC)
+	t := time.Date(to.Year(), to.Month(), to.Day(), 0, 0, 0, 0, time.UTC)
+	return int(t.Sub(f).Hours() / 24)
+}
+
+// monthsBetween возвращает количество календарных месяцев от месяца даты from до месяца даты to.
+func monthsBetween(from, to time.Time) int {
+	return (to.Year()-from.Year())*12 + int(to.Month()) - int(from.Month())
+}
@@ -0,0 +1,72 @@
+package repeat
+
+import (
+	"fmt"
+	"time"
+)
+
+// ToRRule конвертирует правило rule собственного формата с датой начала серии start в правило RRULE,
+// дающее те же даты. Правило RRULE возвращается без изменений. Правила, зависящие от календаря рабочих
+// дней, не конвертируются.
+func ToRRule(rule Rule, start time.Time) (*RRule, error) {
+	if err := rule.Validate(); err != nil {
+		return nil, err
+	}
+	switch r := rule.(type) {
+	case *RRule:
+		return r, nil
+	case *DayRule:
+		return &RRule{Freq: FreqDaily, Interval: r.Step}, nil
+	case *WeekRule:
+		return &RRule{Freq: FreqWeekly, Interval: intervalOrOne(r.Interval), ByDay: byDay(nil, r.Days)}, nil
+	case *MonthRule:
+		return &RRule{Freq: FreqMonthly, Interval: intervalOrOne(r.Interval), ByMonthDay: r.Days, ByMonth: r.Months}, nil
+	case *WeekdayRule:
+		return &RRule{Freq: FreqMonthly, Interval: intervalOrOne(r.Interval), ByDay: byDay(r.Ordinals, r.Weekdays),
+			ByMonth: r.Months}, nil
+	case *YearRule:
+		res := &RRule{Freq: FreqYearly, Interval: intervalOrOne(r.Interval)}
+		if start.Month() == time.February && start.Day() == 29 {
+			// Правило 'y' переносит 29 февраля на 1 марта в невисокосные годы, то есть на 60-й день года.
+			res.ByYearDay = []int{60}
+		}
+		return res, nil
+	case *CronRule:
+		return cronToRRule(r)
+	case Union:
+		return nil, fmt.Errorf("объединение правил не имеет аналога в формате RRULE")
+	}
+	return nil, fmt.Errorf("рабочие дни и перенос на рабочий день не имеют аналога в формате RRULE")
+}
+
+// byDay формирует значение параметра BYDAY из порядковых номеров (ordinals, может отсутствовать)
+// и номеров дней недели (weekdays).
+func byDay(ordinals, weekdays []int) []WeekdayNum {
+	if len(ordinals) == 0 {
+		ordinals = []int{0}
+	}
+	var res []WeekdayNum
+	for _, o := range ordinals {
+		for _, wd := range weekdays {
+			res = append(res, WeekdayNum{Ordinal: o, Weekday: wd})
+		}
+	}
+	return res
+}
+
+// cronToRRule конвертирует правило cron в правило RRULE. Правило, в котором ограничены и дни месяца,
+// и дни недели, не конвертируется, так как RRULE требует соответствия обоим параметрам сразу.
+func cronToRRule(r *CronRule) (*RRule, error) {
+	s := toSpec(r)
+	if s.anyDay {
+		return nil, fmt.Errorf("выражение cron с одновременно ограниченными днями месяца и днями недели не имеет аналога в формате RRULE")
+	}
+	res := &RRule{Freq: FreqDaily, Interval: 1, ByMonth: s.months}
+	switch {
+	case len(s.monthDays) > 0:
+		res.Freq, res.ByMonthDay = FreqMonthly, s.monthDays
+	case len(s.weekdays) > 0:
+		res.Freq, res.ByDay = FreqWeekly, s.weekdays
+	}
+	return res, nil
+}
@@ -0,0 +1,221 @@
+package repeat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// cronSearchDays содержит глубину поиска следующей даты правила cron в днях. Календарь повторяется
+// каждые 28 лет, поэтому дальше искать бессмысленно.
+const cronSearchDays = 28 * 366
+
+// cronDescriptors содержит поддерживаемые сокращённые записи выражений cron.
+var cronDescriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+}
+
+// cronField описывает допустимые значения поля выражения cron.
+type cronField struct {
+	name     string
+	min, max int
+	names    []string // Названия значений, начиная с min
+}
+
+// cronFields содержит описания полей выражения cron в порядке их следования.
+var cronFields = [5]cronField{
+	{name: "минуты", min: 0, max: 59},
+	{name: "часы", min: 0, max: 23},
+	{name: "дни месяца", min: 1, max: 31},
+	{name: "месяцы", min: 1, max: 12,
+		names: []string{"JAN", "FEB", "MAR", "APR", "MAY", "JUN", "JUL", "AUG", "SEP", "OCT", "NOV", "DEC"}},
+	{name: "дни недели", min: 0, max: 7, names: []string{"SUN", "MON", "TUE", "WED", "THU", "FRI", "SAT"}},
+}
+
+// CronRule - правило в формате выражения cron из 5 полей "минуты часы дни-месяца месяцы дни-недели"
+// (дни недели: 0 или 7 - воскресенье). Правило вычисляется с точностью до дня, поэтому поля минут и часов
+// проверяются, но не учитываются. Если ограничены и дни месяца, и дни недели, подходит день,
+// соответствующий любому из этих полей.
+type CronRule struct {
+	Minute   string
+	Hour     string
+	MonthDay string
+	Month    string
+	Weekday  string
+	start    time.Time
+}
+
+// IsCron проверяет, записано ли правило повторения s в формате cron: правила собственного формата
+// начинаются с буквы, а выражения cron - с цифры, '*' или сокращённой записи вида "@weekly".
+func IsCron(s string) bool {
+	return len(s) > 0 && (s[0] == '@' || s[0] == '*' || (s[0] >= '0' && s[0] <= '9'))
+}
+
+// parseCron разбирает правило s в формате cron. Группой в ошибке разбора считается поле выражения.
+func parseCron(s string) (Rule, error) {
+	expr := s
+	if strings.HasPrefix(s, "@") {
+		var ok bool
+		if expr, ok = cronDescriptors[strings.ToLower(s)]; !ok {
+			return nil, &ParseError{Rule: s, Group: 1, Pos: 1,
+				Msg: fmt.Sprintf("неподдерживаемая сокращённая запись cron: '%s' (@yearly, @monthly, @weekly или @daily)", s)}
+		}
+	}
+	p := parser{rule: s}
+	offset := 0
+	for _, g := range strings.Split(expr, " ") {
+		p.groups = append(p.groups, group{text: g, offset: offset})
+		offset += len(g) + 1
+	}
+	if len(p.groups) != len(cronFields) {
+		return nil, &ParseError{Rule: s, Msg: fmt.Sprintf("выражение cron должно состоять из %d полей, разделённых пробелом",
+			len(cronFields))}
+	}
+	for i, f := range cronFields {
+		if _, pos, err := f.parse(p.groups[i].text); err != nil {
+			if expr != s {
+				return nil, &ParseError{Rule: s, Msg: err.Error()}
+			}
+			return nil, p.errorAt(i, pos, "%s", err)
+		}
+	}
+	return &CronRule{Minute: p.groups[0].text, Hour: p.groups[1].text, MonthDay: p.groups[2].text,
+		Month: p.groups[3].text, Weekday: p.groups[4].text}, nil
+}
+
+// parse разбирает значение поля text: список через запятую из значений, диапазонов "a-b" и "*"
+// с необязательным шагом "/s". Возвращает множество значений поля в виде слайса признаков, индексируемого
+// значением. В случае неудачи возвращает смещение неверного элемента в байтах и ошибку.
+func (f cronField) parse(text string) ([]bool, int, error) {
+	set := make([]bool, f.max+1)
+	offset := 0
+	for _, item := range strings.Split(text, ",") {
+		rng, stepText, hasStep := strings.Cut(item, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = atoi(stepText); err != nil || step < 1 {
+				return nil, offset, fmt.Errorf("недопустимый шаг '%s' в поле '%s'", stepText, f.name)
+			}
+		}
+		from, to := f.min, f.max
+		if rng != "*" {
+			a, b, isRange := strings.Cut(rng, "-")
+			var err error
+			if from, err = f.value(a); err != nil {
+				return nil, offset, err
+			}
+			to = from
+			if isRange {
+				if to, err = f.value(b); err != nil {
+					return nil, offset, err
+				}
+			} else if hasStep {
+				to = f.max
+			}
+			if from > to {
+				return nil, offset, fmt.Errorf("недопустимый диапазон '%s' в поле '%s'", rng, f.name)
+			}
+		}
+		for v := from; v <= to; v += step {
+			set[v] = true
+		}
+		offset += len(item) + 1
+	}
+	return set, 0, nil
+}
+
+// value разбирает отдельное значение поля s: число или название (JAN, MON и т.д.).
+func (f cronField) value(s string) (int, error) {
+	for i, name := range f.names {
+		if strings.EqualFold(s, name) {
+			return f.min + i, nil
+		}
+	}
+	v, err := atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("'%s' не является допустимым значением поля '%s'", s, f.name)
+	}
+	if v < f.min || v > f.max {
+		return 0, fmt.Errorf("недопустимое значение поля '%s': %d!(допускается от %d до %d)", f.name, v, f.min, f.max)
+	}
+	return v, nil
+}
+
+// fields возвращает значения полей правила в порядке их следования.
+func (r *CronRule) fields() [5]string {
+	return [5]string{r.Minute, r.Hour, r.MonthDay, r.Month, r.Weekday}
+}
+
+// sets возвращает множества допустимых дней месяца, месяцев и дней недели (0 - воскресенье...6 - суббота),
+// а также признаки ограничения дней месяца и дней недели.
+func (r *CronRule) sets() (days, months, weekdays []bool, dayRestricted, weekdayRestricted bool) {
+	days, _, _ = cronFields[2].parse(r.MonthDay)
+	months, _, _ = cronFields[3].parse(r.Month)
+	weekdays, _, _ = cronFields[4].parse(r.Weekday)
+	if weekdays != nil && weekdays[7] {
+		weekdays[0] = true
+	}
+	return days, months, weekdays, !strings.HasPrefix(r.MonthDay, "*"), !strings.HasPrefix(r.Weekday, "*")
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *CronRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	_, after = window(r.start, after)
+	days, months, weekdays, dayRestricted, weekdayRestricted := r.sets()
+	next := after.AddDate(0, 0, 1)
+	for i := 0; i < cronSearchDays; i++ {
+		day, weekday := days[next.Day()], weekdays[int(next.Weekday())]
+		match := day && weekday
+		if dayRestricted && weekdayRestricted {
+			match = day || weekday
+		}
+		if months[int(next.Month())] && match {
+			return next
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// String возвращает правило в каноническом виде из 5 полей.
+func (r *CronRule) String() string {
+	f := r.fields()
+	return strings.Join(f[:], " ")
+}
+
+// Validate проверяет допустимость значений полей правила.
+func (r *CronRule) Validate() error {
+	for i, text := range r.fields() {
+		if _, _, err := cronFields[i].parse(text); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *CronRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// setValues возвращает упорядоченный список значений множества set.
+func setValues(set []bool) []int {
+	var res []int
+	for v, ok := range set {
+		if ok {
+			res = append(res, v)
+		}
+	}
+	return res
+}
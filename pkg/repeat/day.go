@@ -0,0 +1,190 @@
+package repeat
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// maxShiftDays содержит максимальное количество дней, на которое может быть перенесена дата правила
+// при поиске рабочего дня.
+const maxShiftDays = 31
+
+// Calendar описывает календарь праздничных дней, используемый правилами рабочих дней.
+type Calendar interface {
+	// IsHoliday проверяет, является ли дата t праздничным днём.
+	IsHoliday(t time.Time) bool
+}
+
+// HolidaySet - календарь праздничных дней, заданный множеством дат в формате DateFormat.
+type HolidaySet map[string]bool
+
+// IsHoliday проверяет, присутствует ли дата t в множестве праздничных дней.
+func (h HolidaySet) IsHoliday(t time.Time) bool {
+	return h[t.Format(DateFormat)]
+}
+
+// DefaultCalendar используется правилами рабочих дней, у которых не задан собственный календарь.
+var DefaultCalendar Calendar = HolidaySet{}
+
+// IsBusinessDay проверяет, является ли дата t рабочим днём календаря cal, то есть не субботой,
+// не воскресеньем и не праздничным днём. Если cal равен nil, используется DefaultCalendar.
+func IsBusinessDay(cal Calendar, t time.Time) bool {
+	if cal == nil {
+		cal = DefaultCalendar
+	}
+	if t.Weekday() == time.Saturday || t.Weekday() == time.Sunday {
+		return false
+	}
+	return !cal.IsHoliday(t)
+}
+
+// nextBusinessDay возвращает дату t, если она является рабочим днём календаря cal, иначе ближайший
+// следующий рабочий день. Если рабочего дня нет в течение maxShiftDays дней, возвращает нулевое время.
+func nextBusinessDay(cal Calendar, t time.Time) time.Time {
+	for i := 0; i <= maxShiftDays; i++ {
+		if IsBusinessDay(cal, t) {
+			return t
+		}
+		t = t.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// DayRule - правило дня "d N": задача повторяется каждые Step дней.
+type DayRule struct {
+	Step  int
+	start time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *DayRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	next := start.AddDate(0, 0, r.Step)
+	if next.After(after) {
+		return next
+	}
+	// Пропуск целых шагов, не попадающих в промежуток до after.
+	steps := daysBetween(next, after)/r.Step + 1
+	next = next.AddDate(0, 0, steps*r.Step)
+	for !next.After(after) {
+		next = next.AddDate(0, 0, r.Step)
+	}
+	return next
+}
+
+// String возвращает правило в каноническом виде.
+func (r *DayRule) String() string {
+	return DayLetter + " " + strconv.Itoa(r.Step)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *DayRule) Validate() error {
+	return checkStep(r.Step)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *DayRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// BusinessDayRule - правило рабочего дня "b N": задача повторяется через каждые Step рабочих дней
+// календаря Calendar (DefaultCalendar, если не задан).
+type BusinessDayRule struct {
+	Step     int
+	Calendar Calendar
+	start    time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *BusinessDayRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	next, after := window(r.start, after)
+	for !next.After(after) {
+		for i := 0; i < r.Step; i++ {
+			next = nextBusinessDay(r.Calendar, next.AddDate(0, 0, 1))
+			if next.IsZero() {
+				return next
+			}
+		}
+	}
+	return next
+}
+
+// String возвращает правило в каноническом виде.
+func (r *BusinessDayRule) String() string {
+	return BusinessDayLetter + " " + strconv.Itoa(r.Step)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *BusinessDayRule) Validate() error {
+	return checkStep(r.Step)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *BusinessDayRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// Shifted - модификатор правила ">": даты правила Rule, выпавшие на выходной или праздничный день
+// календаря Calendar (DefaultCalendar, если не задан), переносятся на ближайший следующий рабочий день.
+// Допускается только для правил дня, месяца и года.
+type Shifted struct {
+	Rule     Rule
+	Calendar Calendar
+	start    time.Time
+}
+
+// Next возвращает первую перенесённую дату правила строго после after. Так как перенос сдвигает
+// дату вперёд, учитываются и даты правила, предшествующие after не более чем на maxShiftDays дней.
+func (r *Shifted) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	rule := r.Rule.From(start)
+	from := later(start, after.AddDate(0, 0, -maxShiftDays))
+	for {
+		ruleDate := rule.Next(from)
+		if ruleDate.IsZero() {
+			return ruleDate
+		}
+		next := nextBusinessDay(r.Calendar, ruleDate)
+		if next.IsZero() || next.After(after) {
+			return next
+		}
+		from = ruleDate
+	}
+}
+
+// String возвращает правило в каноническом виде.
+func (r *Shifted) String() string {
+	return r.Rule.String() + " " + ShiftModifier
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *Shifted) Validate() error {
+	switch r.Rule.(type) {
+	case *DayRule, *MonthRule, *YearRule:
+		return r.Rule.Validate()
+	case nil:
+		return fmt.Errorf("не указано правило для переноса на рабочий день")
+	}
+	return fmt.Errorf("перенос на рабочий день ('%s') допустим только для правил 'd', 'm' и 'y'", ShiftModifier)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *Shifted) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
@@ -0,0 +1,432 @@
+package repeat
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Lang - язык текстового описания правила повторения.
+type Lang string
+
+const (
+	LangRu Lang = "ru" // Русский язык
+	LangEn Lang = "en" // Английский язык
+)
+
+// ParseLang возвращает язык описания по его коду ("ru", "en", а также "ru-RU", "en-US" и т.п.).
+// Пустой код соответствует русскому языку.
+func ParseLang(code string) (Lang, error) {
+	base, _, _ := strings.Cut(strings.ToLower(code), "-")
+	switch Lang(base) {
+	case "", LangRu:
+		return LangRu, nil
+	case LangEn:
+		return LangEn, nil
+	}
+	return "", fmt.Errorf("неподдерживаемый язык описания: '%s' (ru или en)", code)
+}
+
+// Единицы периода повторения в описании правила.
+const (
+	unitDay = iota
+	unitWeek
+	unitMonth
+	unitYear
+)
+
+// spec содержит общее для всех типов правил представление, по которому строится текстовое описание.
+type spec struct {
+	unit      int
+	interval  int
+	business  bool
+	shift     bool
+	weekdays  []WeekdayNum
+	monthDays []int
+	months    []int
+	yearDays  []int
+	setPos    []int
+	count     int
+	until     time.Time
+	anyDay    bool // Подходит день, соответствующий дням месяца или дням недели
+}
+
+// Describe возвращает описание правила rule на естественном языке lang, например "каждый первый и
+// последний день февраля и августа" или "on the 1st and last day of February and August".
+func Describe(rule Rule, lang Lang) string {
+	if union, ok := rule.(Union); ok {
+		var parts []string
+		for _, r := range union {
+			parts = append(parts, Describe(r, lang))
+		}
+		return strings.Join(parts, UnionSeparator+" ")
+	}
+	s := toSpec(rule)
+	if lang == LangEn {
+		return s.english()
+	}
+	return s.russian()
+}
+
+// toSpec приводит правило rule к представлению spec.
+func toSpec(rule Rule) spec {
+	switch r := rule.(type) {
+	case *DayRule:
+		return spec{unit: unitDay, interval: r.Step}
+	case *BusinessDayRule:
+		return spec{unit: unitDay, interval: r.Step, business: true}
+	case *WeekRule:
+		return spec{unit: unitWeek, interval: intervalOrOne(r.Interval), weekdays: byDay(nil, r.Days)}
+	case *MonthRule:
+		return spec{unit: unitMonth, interval: intervalOrOne(r.Interval), monthDays: r.Days, months: r.Months}
+	case *WeekdayRule:
+		return spec{unit: unitMonth, interval: intervalOrOne(r.Interval), weekdays: byDay(r.Ordinals, r.Weekdays),
+			months: r.Months}
+	case *YearRule:
+		return spec{unit: unitYear, interval: intervalOrOne(r.Interval)}
+	case *Shifted:
+		s := toSpec(r.Rule)
+		s.shift = true
+		return s
+	case *RRule:
+		units := map[string]int{FreqDaily: unitDay, FreqWeekly: unitWeek, FreqMonthly: unitMonth, FreqYearly: unitYear}
+		return spec{unit: units[r.Freq], interval: intervalOrOne(r.Interval), weekdays: r.ByDay,
+			monthDays: r.ByMonthDay, months: r.ByMonth, yearDays: r.ByYearDay, setPos: r.BySetPos,
+			count: r.Count, until: r.Until}
+	case *CronRule:
+		days, months, weekdays, dayRestricted, weekdayRestricted := r.sets()
+		s := spec{unit: unitDay, interval: 1, anyDay: dayRestricted && weekdayRestricted}
+		if len(setValues(days)) < 31 {
+			s.monthDays = setValues(days)
+		}
+		if len(setValues(weekdays[:7])) < DaysInWeek {
+			for _, wd := range setValues(weekdays[1:7]) {
+				s.weekdays = append(s.weekdays, WeekdayNum{Weekday: wd + 1})
+			}
+			if weekdays[0] {
+				s.weekdays = append(s.weekdays, WeekdayNum{Weekday: DaysInWeek})
+			}
+		}
+		if len(setValues(months)) < 12 {
+			s.months = setValues(months)
+		}
+		if s.anyDay && (len(s.monthDays) == 0 || len(s.weekdays) == 0) {
+			// Одно из полей допускает любой день, поэтому подходит каждый день.
+			s.monthDays, s.weekdays, s.anyDay = nil, nil, false
+		}
+		return s
+	}
+	return spec{unit: unitDay, interval: 1}
+}
+
+// hasOrdinals проверяет, содержит ли список дней недели порядковые номера.
+func (s spec) hasOrdinals() bool {
+	for _, wd := range s.weekdays {
+		if wd.Ordinal != 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// filtered проверяет, ограничено ли правило конкретными днями, месяцами или днями года.
+func (s spec) filtered() bool {
+	return len(s.weekdays)+len(s.monthDays)+len(s.months)+len(s.yearDays) > 0
+}
+
+// groupByWeekday группирует элементы BYDAY по дням недели с сохранением порядка их появления.
+func groupByWeekday(list []WeekdayNum) ([]int, map[int][]int) {
+	var order []int
+	ordinals := make(map[int][]int)
+	for _, wd := range list {
+		if _, ok := ordinals[wd.Weekday]; !ok {
+			order = append(order, wd.Weekday)
+		}
+		ordinals[wd.Weekday] = append(ordinals[wd.Weekday], wd.Ordinal)
+	}
+	return order, ordinals
+}
+
+// joinWords объединяет слова в перечисление через запятую с союзом conj перед последним словом.
+func joinWords(words []string, conj string) string {
+	if len(words) < 2 {
+		return strings.Join(words, "")
+	}
+	return strings.Join(words[:len(words)-1], ", ") + " " + conj + " " + words[len(words)-1]
+}
+
+// mapInts возвращает результат применения функции f к каждому числу списка values.
+func mapInts(values []int, f func(int) string) []string {
+	var res []string
+	for _, v := range values {
+		res = append(res, f(v))
+	}
+	return res
+}
+
+var (
+	ruWeekdaysAcc = [...]string{1: "понедельник", 2: "вторник", 3: "среду", 4: "четверг", 5: "пятницу",
+		6: "субботу", 7: "воскресенье"}
+	ruWeekdaysDat = [...]string{1: "понедельникам", 2: "вторникам", 3: "средам", 4: "четвергам", 5: "пятницам",
+		6: "субботам", 7: "воскресеньям"}
+	// ruWeekdayGender содержит род названий дней недели: 0 - мужской, 1 - женский, 2 - средний.
+	ruWeekdayGender = [...]int{1: 0, 2: 0, 3: 1, 4: 0, 5: 1, 6: 1, 7: 2}
+	// ruOrdinalsAcc содержит порядковые числительные в винительном падеже по родам.
+	ruOrdinalsAcc = [3]map[int]string{
+		{1: "первый", 2: "второй", 3: "третий", 4: "четвёртый", 5: "пятый", -1: "последний", -2: "предпоследний"},
+		{1: "первую", 2: "вторую", 3: "третью", 4: "четвёртую", 5: "пятую", -1: "последнюю", -2: "предпоследнюю"},
+		{1: "первое", 2: "второе", 3: "третье", 4: "четвёртое", 5: "пятое", -1: "последнее", -2: "предпоследнее"},
+	}
+	ruOrdinalSuffix = [3]string{"й", "ю", "е"}
+	ruDayOrdinals   = [...]string{"", "первый", "второй", "третий", "четвёртый", "пятый", "шестой", "седьмой",
+		"восьмой", "девятый", "десятый", "одиннадцатый", "двенадцатый", "тринадцатый", "четырнадцатый",
+		"пятнадцатый", "шестнадцатый", "семнадцатый", "восемнадцатый", "девятнадцатый", "двадцатый",
+		"двадцать первый", "двадцать второй", "двадцать третий", "двадцать четвёртый", "двадцать пятый",
+		"двадцать шестой", "двадцать седьмой", "двадцать восьмой", "двадцать девятый", "тридцатый",
+		"тридцать первый"}
+	ruMonthsGen = [...]string{1: "января", 2: "февраля", 3: "марта", 4: "апреля", 5: "мая", 6: "июня",
+		7: "июля", 8: "августа", 9: "сентября", 10: "октября", 11: "ноября", 12: "декабря"}
+	ruMonthsPrep = [...]string{1: "январе", 2: "феврале", 3: "марте", 4: "апреле", 5: "мае", 6: "июне",
+		7: "июле", 8: "августе", 9: "сентябре", 10: "октябре", 11: "ноябре", 12: "декабре"}
+	// ruUnits содержит формы названий единиц периода для чисел 1, 2 и 5 в винительном падеже.
+	ruUnits = [...][3]string{
+		unitDay:   {"день", "дня", "дней"},
+		unitWeek:  {"неделю", "недели", "недель"},
+		unitMonth: {"месяц", "месяца", "месяцев"},
+		unitYear:  {"год", "года", "лет"},
+	}
+	ruEvery = [...]string{unitDay: "каждый день", unitWeek: "каждую неделю", unitMonth: "каждый месяц",
+		unitYear: "каждый год"}
+)
+
+// ruPlural возвращает форму слова из forms (для чисел 1, 2 и 5), согласованную с числом n.
+func ruPlural(n int, forms [3]string) string {
+	switch {
+	case n%10 == 1 && n%100 != 11:
+		return forms[0]
+	case n%10 >= 2 && n%10 <= 4 && (n%100 < 12 || n%100 > 14):
+		return forms[1]
+	}
+	return forms[2]
+}
+
+// ruOrdinal возвращает порядковое числительное o в винительном падеже рода gender.
+func ruOrdinal(o, gender int) string {
+	if s, ok := ruOrdinalsAcc[gender][o]; ok {
+		return s
+	}
+	if o < 0 {
+		return fmt.Sprintf("%d-%s с конца", -o, ruOrdinalSuffix[gender])
+	}
+	return fmt.Sprintf("%d-%s", o, ruOrdinalSuffix[gender])
+}
+
+// ruDayOrdinal возвращает порядковое числительное дня месяца d (отрицательные - с конца месяца).
+func ruDayOrdinal(d int) string {
+	if d > 0 && d < len(ruDayOrdinals) {
+		return ruDayOrdinals[d]
+	}
+	return ruOrdinal(d, 0)
+}
+
+// ruPeriod возвращает описание периода повторения без уточнения дней ("каждые 2 дня", "раз в 3 месяца").
+func (s spec) ruPeriod() string {
+	if s.interval == 1 {
+		if s.business {
+			return "каждый рабочий день"
+		}
+		return ruEvery[s.unit]
+	}
+	if s.unit != unitDay {
+		return fmt.Sprintf("раз в %d %s", s.interval, ruPlural(s.interval, ruUnits[s.unit]))
+	}
+	if s.business {
+		return fmt.Sprintf("%s %d %s", ruPlural(s.interval, [3]string{"каждый", "каждые", "каждые"}), s.interval,
+			ruPlural(s.interval, [3]string{"рабочий день", "рабочих дня", "рабочих дней"}))
+	}
+	return fmt.Sprintf("%s %d %s", ruPlural(s.interval, [3]string{"каждый", "каждые", "каждые"}), s.interval,
+		ruPlural(s.interval, ruUnits[unitDay]))
+}
+
+// russian возвращает описание правила на русском языке.
+func (s spec) russian() string {
+	var parts []string
+	if !s.filtered() {
+		parts = append(parts, s.ruPeriod())
+	} else {
+		months := mapInts(s.months, func(m int) string { return ruMonthsGen[m] })
+		ofMonths := "месяца"
+		if len(months) > 0 {
+			ofMonths = joinWords(months, "и")
+		}
+		var days []string
+		switch {
+		case len(s.monthDays) > 0:
+			days = append(days, "каждый "+joinWords(mapInts(s.monthDays, ruDayOrdinal), "и")+" день "+ofMonths)
+		case len(s.yearDays) > 0:
+			days = append(days, "в "+joinWords(mapInts(s.yearDays, func(d int) string { return ruOrdinal(d, 0) }),
+				"и")+" день года")
+		case s.hasOrdinals():
+			order, ordinals := groupByWeekday(s.weekdays)
+			var list []string
+			for _, wd := range order {
+				g := ruWeekdayGender[wd]
+				list = append(list, joinWords(mapInts(ordinals[wd], func(o int) string { return ruOrdinal(o, g) }), "и")+
+					" "+ruWeekdaysAcc[wd])
+			}
+			if s.unit == unitYear && len(months) == 0 {
+				// Без BYMONTH порядковые номера RRULE отсчитываются внутри года.
+				ofMonths = "года"
+			}
+			days = append(days, "в "+joinWords(list, "и")+" "+ofMonths)
+		case len(s.weekdays) == 0:
+			days = append(days, "каждый день")
+		}
+		if !s.hasOrdinals() && len(s.weekdays) > 0 {
+			order, _ := groupByWeekday(s.weekdays)
+			days = append(days, "по "+joinWords(mapInts(order, func(wd int) string { return ruWeekdaysDat[wd] }), "и"))
+		}
+		if len(months) > 0 && len(s.monthDays) == 0 && !s.hasOrdinals() {
+			days = append(days, "в "+joinWords(mapInts(s.months, func(m int) string { return ruMonthsPrep[m] }), "и"))
+		}
+		sep := " "
+		if s.anyDay {
+			sep = " или "
+		}
+		parts = append(parts, strings.Join(days, sep))
+		if s.interval > 1 {
+			parts = append(parts, s.ruPeriod())
+		}
+	}
+	if len(s.setPos) > 0 {
+		parts = append(parts, "только "+joinWords(mapInts(s.setPos, func(p int) string { return ruOrdinal(p, 2) }),
+			"и")+" совпадение в периоде")
+	}
+	if s.count > 0 {
+		parts = append(parts, fmt.Sprintf("всего %d %s", s.count, ruPlural(s.count, [3]string{"раз", "раза", "раз"})))
+	}
+	if !s.until.IsZero() {
+		parts = append(parts, "до "+s.until.Format("02.01.2006")+" включительно")
+	}
+	if s.shift {
+		parts = append(parts, "с переносом на ближайший рабочий день")
+	}
+	return strings.Join(parts, ", ")
+}
+
+var (
+	enWeekdays = [...]string{1: "Monday", 2: "Tuesday", 3: "Wednesday", 4: "Thursday", 5: "Friday",
+		6: "Saturday", 7: "Sunday"}
+	enMonths = [...]string{1: "January", 2: "February", 3: "March", 4: "April", 5: "May", 6: "June",
+		7: "July", 8: "August", 9: "September", 10: "October", 11: "November", 12: "December"}
+	enUnits = [...]string{unitDay: "day", unitWeek: "week", unitMonth: "month", unitYear: "year"}
+)
+
+// enOrdinal возвращает английское порядковое числительное n ("1st", "last", "2nd to last").
+func enOrdinal(n int) string {
+	switch n {
+	case -1:
+		return "last"
+	case -2:
+		return "second-to-last"
+	}
+	if n < 0 {
+		return enOrdinal(-n) + " to last"
+	}
+	suffix := "th"
+	if n%100 < 11 || n%100 > 13 {
+		switch n % 10 {
+		case 1:
+			suffix = "st"
+		case 2:
+			suffix = "nd"
+		case 3:
+			suffix = "rd"
+		}
+	}
+	return strconv.Itoa(n) + suffix
+}
+
+// enPeriod возвращает описание периода повторения без уточнения дней ("every 2 days", "every month").
+func (s spec) enPeriod() string {
+	unit := enUnits[s.unit]
+	if s.business {
+		unit = "business day"
+	}
+	if s.interval == 1 {
+		return "every " + unit
+	}
+	return fmt.Sprintf("every %d %ss", s.interval, unit)
+}
+
+// english возвращает описание правила на английском языке.
+func (s spec) english() string {
+	var parts []string
+	if !s.filtered() {
+		parts = append(parts, s.enPeriod())
+	} else {
+		months := joinWords(mapInts(s.months, func(m int) string { return enMonths[m] }), "and")
+		ofMonths := "of every month"
+		if s.interval > 1 {
+			ofMonths = "of the month"
+		}
+		if len(s.months) > 0 {
+			ofMonths = "of " + months
+		}
+		var days []string
+		switch {
+		case len(s.monthDays) > 0:
+			days = append(days, "on the "+joinWords(mapInts(s.monthDays, enOrdinal), "and")+" day "+ofMonths)
+		case len(s.yearDays) > 0:
+			days = append(days, "on the "+joinWords(mapInts(s.yearDays, enOrdinal), "and")+" day of the year")
+		case s.hasOrdinals():
+			order, ordinals := groupByWeekday(s.weekdays)
+			var list []string
+			for _, wd := range order {
+				list = append(list, joinWords(mapInts(ordinals[wd], enOrdinal), "and")+" "+enWeekdays[wd])
+			}
+			if s.unit == unitYear && len(s.months) == 0 {
+				ofMonths = "of the year"
+			}
+			days = append(days, "on the "+joinWords(list, "and")+" "+ofMonths)
+		case len(s.weekdays) == 0:
+			days = append(days, "every day")
+		}
+		if !s.hasOrdinals() && len(s.weekdays) > 0 {
+			order, _ := groupByWeekday(s.weekdays)
+			names := joinWords(mapInts(order, func(wd int) string { return enWeekdays[wd] }), "and")
+			if len(days) == 0 {
+				days = append(days, "every "+names)
+			} else {
+				days = append(days, "on "+names)
+			}
+		}
+		if len(s.months) > 0 && len(s.monthDays) == 0 && !s.hasOrdinals() {
+			days = append(days, "in "+months)
+		}
+		sep := " "
+		if s.anyDay {
+			sep = " or "
+		}
+		parts = append(parts, strings.Join(days, sep))
+		if s.interval > 1 {
+			parts = append(parts, s.enPeriod())
+		}
+	}
+	if len(s.setPos) > 0 {
+		parts = append(parts, "only the "+joinWords(mapInts(s.setPos, enOrdinal), "and")+" occurrence in each period")
+	}
+	if s.count == 1 {
+		parts = append(parts, "once")
+	} else if s.count > 1 {
+		parts = append(parts, fmt.Sprintf("%d times", s.count))
+	}
+	if !s.until.IsZero() {
+		parts = append(parts, "until "+s.until.Format("2006-01-02"))
+	}
+	if s.shift {
+		parts = append(parts, "moved to the next business day")
+	}
+	return strings.Join(parts, ", ")
+}
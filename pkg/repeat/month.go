@@ -0,0 +1,164 @@
+package repeat
+
+import (
+	"time"
+)
+
+// searchYears содержит глубину поиска следующей даты правил месяца в годах на единицу интервала.
+// Календарь повторяется каждые 28 лет, поэтому дальше искать бессмысленно.
+const searchYears = 28
+
+// MonthRule - правило месяца "m <дни месяца> [месяцы] [/N]": задача повторяется в указанные дни месяца
+// (1...31, -1 - последний, -2 - предпоследний) указанных месяцев (все месяцы, если Months пуст) каждого
+// Interval-го месяца, отсчитываемого от месяца начала серии. Дни, отсутствующие в месяце, пропускаются.
+type MonthRule struct {
+	Days     []int
+	Months   []int
+	Interval int
+	start    time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *MonthRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	return nextInMonths(start, after, r.Months, r.Interval, func(month time.Time) []time.Time {
+		var dates []time.Time
+		last := daysIn(month)
+		for _, d := range r.Days {
+			if d < 0 {
+				d = last + d + 1
+			}
+			if d <= last {
+				dates = append(dates, month.AddDate(0, 0, d-1))
+			}
+		}
+		return dates
+	})
+}
+
+// String возвращает правило в каноническом виде.
+func (r *MonthRule) String() string {
+	s := MonthLetter + " " + formatList(r.Days)
+	if len(r.Months) > 0 {
+		s += " " + formatList(r.Months)
+	}
+	return s + formatInterval(r.Interval)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *MonthRule) Validate() error {
+	if err := checkList("дни месяца", r.Days, checkMonthDay); err != nil {
+		return err
+	}
+	if err := checkOptionalList(r.Months, checkMonth); err != nil {
+		return err
+	}
+	return checkIntervalOrZero(r.Interval)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *MonthRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// WeekdayRule - правило n-го дня недели месяца "n <порядковые номера> <дни недели> [месяцы] [/N]":
+// задача повторяется в дни недели (пн-1...вс-7) с указанными порядковыми номерами в месяце (1...5,
+// -1 - последний) указанных месяцев (все месяцы, если Months пуст) каждого Interval-го месяца,
+// отсчитываемого от месяца начала серии.
+type WeekdayRule struct {
+	Ordinals []int
+	Weekdays []int
+	Months   []int
+	Interval int
+	start    time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *WeekdayRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	return nextInMonths(start, after, r.Months, r.Interval, func(month time.Time) []time.Time {
+		var dates []time.Time
+		for _, o := range r.Ordinals {
+			for _, wd := range r.Weekdays {
+				if d, ok := WeekdayOfMonth(month, o, wd); ok {
+					dates = append(dates, d)
+				}
+			}
+		}
+		return dates
+	})
+}
+
+// String возвращает правило в каноническом виде.
+func (r *WeekdayRule) String() string {
+	s := WeekdayLetter + " " + formatList(r.Ordinals) + " " + formatList(r.Weekdays)
+	if len(r.Months) > 0 {
+		s += " " + formatList(r.Months)
+	}
+	return s + formatInterval(r.Interval)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *WeekdayRule) Validate() error {
+	if err := checkList("порядковые номера дней недели", r.Ordinals, checkOrdinal); err != nil {
+		return err
+	}
+	if err := checkList("дни недели", r.Weekdays, checkWeekday); err != nil {
+		return err
+	}
+	if err := checkOptionalList(r.Months, checkMonth); err != nil {
+		return err
+	}
+	return checkIntervalOrZero(r.Interval)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *WeekdayRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// WeekdayOfMonth возвращает дату, соответствующую порядковому номеру ordinal (1...5, -1 - последний)
+// дня недели weekday (пн-1...вс-7) в месяце, первое число которого передано в month. Если такой даты
+// в месяце нет, возвращает false.
+func WeekdayOfMonth(month time.Time, ordinal, weekday int) (time.Time, bool) {
+	if ordinal == -1 {
+		last := month.AddDate(0, 1, -1)
+		return last.AddDate(0, 0, -((Weekday(last) - weekday + DaysInWeek) % DaysInWeek)), true
+	}
+	d := month.AddDate(0, 0, (weekday-Weekday(month)+DaysInWeek)%DaysInWeek+DaysInWeek*(ordinal-1))
+	return d, d.Month() == month.Month()
+}
+
+// nextInMonths возвращает самую раннюю дату строго после after среди дат, которые функция dates
+// возвращает для подходящих месяцев: входящих в список months (все месяцы, если он пуст) и отстоящих
+// от месяца начала серии start на кратное interval количество месяцев. Если такой даты нет,
+// возвращает нулевое время.
+func nextInMonths(start, after time.Time, months []int, interval int, dates func(month time.Time) []time.Time) time.Time {
+	interval = intervalOrOne(interval)
+	month := startOfMonth(after)
+	for i := 0; i <= searchYears*12*interval; i++ {
+		if (len(months) == 0 || contains(months, int(month.Month()))) && monthsBetween(start, month)%interval == 0 {
+			var next time.Time
+			for _, d := range dates(month) {
+				if d.After(after) && (next.IsZero() || d.Before(next)) {
+					next = d
+				}
+			}
+			if !next.IsZero() {
+				return next
+			}
+		}
+		month = month.AddDate(0, 1, 0)
+	}
+	return time.Time{}
+}
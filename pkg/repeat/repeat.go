@@ -0,0 +1,263 @@
+// Пакет repeat реализует разбор, проверку и вычисление правил повторения задач планировщика.
+// Поддерживаются собственный формат правил ("d 7", "b 1", "w 1,3 /2", "m 1,-1 2,8", "n -1 5",
+// "y /2", модификатор переноса на рабочий день ">"), правила RRULE по RFC 5545 и выражения cron.
+package repeat
+
+import (
+	"fmt"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// DateFormat содержит строковый формат представления даты в правилах.
+const DateFormat = "20060102"
+
+const (
+	DaysInWeek  = 7   // Количество дней в неделе
+	MaxStep     = 400 // Максимальный шаг повторения в правилах 'd' и 'b'
+	MaxInterval = 100 // Максимальный интервал повторения в правилах 'w', 'm', 'n' и 'y'
+	MaxOrdinal  = 5   // Максимальный порядковый номер дня недели в месяце в правиле 'n'
+)
+
+const (
+	DayLetter         = "d" // Индикатор дня в правиле задачи
+	BusinessDayLetter = "b" // Индикатор рабочего дня в правиле задачи
+	WeekLetter        = "w" // Индикатор недели в правиле задачи
+	MonthLetter       = "m" // Индикатор месяца в правиле задачи
+	WeekdayLetter     = "n" // Индикатор n-го дня недели месяца в правиле задачи
+	YearLetter        = "y" // Индикатор года в правиле задачи
+	IntervalPrefix    = "/" // Префикс группы интервала в правиле задачи
+	ShiftModifier     = ">" // Группа переноса даты на ближайший рабочий день
+	UnionSeparator    = ";" // Разделитель правил в объединении правил
+)
+
+// Rule описывает разобранное правило повторения. Даты правила отсчитываются от даты начала серии,
+// задаваемой методом From, и следуют строго после неё; если она не задана, началом серии считается
+// дата, переданная в Next.
+type Rule interface {
+	// Next возвращает первую дату правила строго после даты after. Если правило больше не даёт
+	// дат или не проходит проверку Validate, возвращает нулевое время.
+	Next(after time.Time) time.Time
+	// String возвращает правило в каноническом виде, пригодном для повторного разбора Parse.
+	String() string
+	// Validate проверяет допустимость значений правила.
+	Validate() error
+	// From возвращает копию правила с датой начала серии start.
+	From(start time.Time) Rule
+}
+
+// ParseError описывает ошибку разбора правила повторения.
+type ParseError struct {
+	Rule  string // Исходная строка правила
+	Part  int    // Номер правила в объединении правил (с 1), либо 0, если правило не является объединением
+	Group int    // Номер группы правила, содержащей ошибку (с 1), либо 0 для правила целиком
+	Pos   int    // Позиция символа в исходной строке, с которого начинается ошибка (с 1), либо 0 для правила целиком
+	Msg   string // Описание ошибки
+}
+
+// Error возвращает описание ошибки разбора с указанием части объединения, группы и позиции.
+func (e *ParseError) Error() string {
+	where := fmt.Sprintf("правило '%s'", e.Rule)
+	if e.Part > 0 {
+		where += fmt.Sprintf(", часть %d", e.Part)
+	}
+	if e.Group > 0 {
+		where += fmt.Sprintf(", группа %d, позиция %d", e.Group, e.Pos)
+	}
+	return where + ": " + e.Msg
+}
+
+// Parse разбирает и проверяет правило повторения s в собственном формате, в формате RRULE или в формате
+// выражения cron, а также объединение таких правил через UnionSeparator. В случае неудачи возвращает
+// ошибку *ParseError.
+func Parse(s string) (Rule, error) {
+	if len(s) == 0 {
+		return nil, &ParseError{Rule: s, Msg: "правило повторения отсутствует"}
+	}
+	if parts := splitUnion(s); len(parts) > 1 {
+		return parseUnion(s, parts)
+	}
+	return parseSingle(s)
+}
+
+// parseSingle разбирает правило s, не являющееся объединением правил.
+func parseSingle(s string) (Rule, error) {
+	if IsRRule(s) {
+		return parseRRule(s)
+	}
+	if IsCron(s) {
+		return parseCron(s)
+	}
+	return parseLegacy(s)
+}
+
+// group соответствует группе правила, отделённой пробелом, и её смещению в байтах от начала правила.
+type group struct {
+	text   string
+	offset int
+}
+
+// parser хранит состояние разбора правила в собственном формате.
+type parser struct {
+	rule   string
+	groups []group
+}
+
+// errorAt возвращает ошибку разбора для группы с индексом i и смещением offset внутри этой группы.
+func (p *parser) errorAt(i, offset int, format string, args ...any) error {
+	pos := p.groups[i].offset + offset
+	return &ParseError{
+		Rule:  p.rule,
+		Group: i + 1,
+		Pos:   utf8.RuneCountInString(p.rule[:pos]) + 1,
+		Msg:   fmt.Sprintf(format, args...),
+	}
+}
+
+// list разбирает группу с индексом i как список чисел через запятую. Каждое число проверяется
+// функцией check. В случае неудачи возвращает ошибку с позицией неверного числа.
+func (p *parser) list(i int, check func(int) error) ([]int, error) {
+	var res []int
+	offset := 0
+	for _, item := range strings.Split(p.groups[i].text, ",") {
+		v, err := atoi(item)
+		if err != nil {
+			return nil, p.errorAt(i, offset, "'%s' не является целым числом", item)
+		}
+		if err := check(v); err != nil {
+			return nil, p.errorAt(i, offset, "%s", err)
+		}
+		res = append(res, v)
+		offset += len(item) + 1
+	}
+	return res, nil
+}
+
+// parseLegacy разбирает правило s в собственном формате.
+func parseLegacy(s string) (Rule, error) {
+	p := parser{rule: s}
+	offset := 0
+	for _, g := range strings.Split(s, " ") {
+		p.groups = append(p.groups, group{text: g, offset: offset})
+		offset += len(g) + 1
+	}
+	n := len(p.groups)
+	var shift bool
+	if n > 1 && p.groups[n-1].text == ShiftModifier {
+		letter := p.groups[0].text
+		if letter != DayLetter && letter != MonthLetter && letter != YearLetter {
+			return nil, p.errorAt(n-1, 0, "перенос на рабочий день ('%s') допустим только для правил 'd', 'm' и 'y'",
+				ShiftModifier)
+		}
+		shift = true
+		n--
+	}
+	interval := 1
+	if n > 1 && strings.HasPrefix(p.groups[n-1].text, IntervalPrefix) {
+		letter := p.groups[0].text
+		if letter == DayLetter || letter == BusinessDayLetter {
+			return nil, p.errorAt(n-1, 0, "в правиле '%s' интервал задаётся самим шагом повторения", letter)
+		}
+		var err error
+		interval, err = atoi(strings.TrimPrefix(p.groups[n-1].text, IntervalPrefix))
+		if err != nil {
+			return nil, p.errorAt(n-1, 0, "недопустимая группа интервала '%s' (ожидается '%sN')",
+				p.groups[n-1].text, IntervalPrefix)
+		}
+		if err := checkInterval(interval); err != nil {
+			return nil, p.errorAt(n-1, len(IntervalPrefix), "%s", err)
+		}
+		n--
+	}
+	rule, err := p.parseRule(n, interval)
+	if err != nil {
+		return nil, err
+	}
+	if shift {
+		rule = &Shifted{Rule: rule}
+	}
+	return rule, nil
+}
+
+// parseRule разбирает первые n групп правила (без групп интервала и переноса) с интервалом interval.
+func (p *parser) parseRule(n, interval int) (Rule, error) {
+	letter := p.groups[0].text
+	wantGroups := func(min, max int) error {
+		switch {
+		case n-1 < min:
+			return &ParseError{Rule: p.rule, Msg: fmt.Sprintf("после правила '%s' не хватает групп (ожидается от %d до %d)",
+				letter, min, max)}
+		case n-1 > max:
+			return p.errorAt(max+1, 0, "после правила '%s' может быть описано не более %d групп", letter, max)
+		}
+		return nil
+	}
+	switch letter {
+	case DayLetter, BusinessDayLetter:
+		if err := wantGroups(1, 1); err != nil {
+			return nil, err
+		}
+		step, err := p.list(1, checkStep)
+		if err != nil {
+			return nil, err
+		}
+		if len(step) != 1 {
+			return nil, p.errorAt(1, 0, "избыточное количество параметров в правиле '%s'", letter)
+		}
+		if letter == BusinessDayLetter {
+			return &BusinessDayRule{Step: step[0]}, nil
+		}
+		return &DayRule{Step: step[0]}, nil
+	case WeekLetter:
+		if err := wantGroups(1, 1); err != nil {
+			return nil, err
+		}
+		days, err := p.list(1, checkWeekday)
+		if err != nil {
+			return nil, err
+		}
+		return &WeekRule{Days: days, Interval: interval}, nil
+	case MonthLetter:
+		if err := wantGroups(1, 2); err != nil {
+			return nil, err
+		}
+		days, err := p.list(1, checkMonthDay)
+		if err != nil {
+			return nil, err
+		}
+		var months []int
+		if n == 3 {
+			if months, err = p.list(2, checkMonth); err != nil {
+				return nil, err
+			}
+		}
+		return &MonthRule{Days: days, Months: months, Interval: interval}, nil
+	case WeekdayLetter:
+		if err := wantGroups(2, 3); err != nil {
+			return nil, err
+		}
+		ordinals, err := p.list(1, checkOrdinal)
+		if err != nil {
+			return nil, err
+		}
+		weekdays, err := p.list(2, checkWeekday)
+		if err != nil {
+			return nil, err
+		}
+		var months []int
+		if n == 4 {
+			if months, err = p.list(3, checkMonth); err != nil {
+				return nil, err
+			}
+		}
+		return &WeekdayRule{Ordinals: ordinals, Weekdays: weekdays, Months: months, Interval: interval}, nil
+	case YearLetter:
+		if err := wantGroups(0, 0); err != nil {
+			return nil, err
+		}
+		return &YearRule{Interval: interval}, nil
+	}
+	return nil, p.errorAt(0, 0, "недопустимый символ, указывающий на тип правила: '%s' ('d', 'b', 'w', 'm', 'n' или 'y')",
+		letter)
+}
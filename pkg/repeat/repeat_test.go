@@ -0,0 +1,89 @@
+package repeat
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// date возвращает дату в формате DateFormat как время.
+func date(t *testing.T, s string) time.Time {
+	d, err := time.Parse(DateFormat, s)
+	require.NoError(t, err)
+	return d
+}
+
+func TestNext(t *testing.T) {
+	for _, v := range []struct {
+		rule   string
+		dstart string
+		after  string
+		want   string
+	}{
+		{"d 7", "20240101", "20240101", "20240108"},
+		{"d 7", "20240101", "20240120", "20240122"},
+		{"b 1", "20240105", "20240105", "20240108"},
+		{"d 5 >", "20240101", "20240101", "20240108"},
+		{"w 1,5", "20240101", "20240101", "20240105"},
+		{"w 1 /2", "20240101", "20240101", "20240115"},
+		{"m 1,-1", "20240101", "20240101", "20240131"},
+		{"m 30 2,3", "20240101", "20240101", "20240330"},
+		{"n -1 5", "20240101", "20240101", "20240126"},
+		{"y", "20240229", "20240229", "20250301"},
+		{"RRULE:FREQ=WEEKLY;BYDAY=TU,TH", "20240101", "20240103", "20240104"},
+		{"RRULE:FREQ=DAILY;COUNT=3", "20240101", "20240102", "20240103"},
+		{"RRULE:FREQ=DAILY;COUNT=3", "20240101", "20240103", ""},
+		{"m 1; w 7", "20240101", "20240101", "20240107"},
+	} {
+		rule, err := Parse(v.rule)
+		if !assert.NoError(t, err, v.rule) {
+			continue
+		}
+		var want time.Time
+		if len(v.want) > 0 {
+			want = date(t, v.want)
+		}
+		assert.Equal(t, want, rule.From(date(t, v.dstart)).Next(date(t, v.after)), "%s от %s после %s",
+			v.rule, v.dstart, v.after)
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, rule := range []string{"", "d", "d 0", "d 401", "k 1", "w 8", "m 32", "m 1 13", "n 6 1",
+		"y 2", "w 1 >", "RRULE:FREQ=HOURLY", "RRULE:FREQ=DAILY;COUNT=0", "m 1;", "60 * * * *"} {
+		_, err := Parse(rule)
+		var perr *ParseError
+		assert.True(t, errors.As(err, &perr), "ожидается ошибка разбора для '%s'", rule)
+	}
+}
+
+func TestString(t *testing.T) {
+	for _, s := range []string{"d 7", "b 2", "w 1,5 /2", "m 1,-1 2,8", "n -1 5", "y /2", "d 3 >",
+		"RRULE:FREQ=MONTHLY;BYDAY=-1FR;COUNT=5", "m 1; w 7"} {
+		rule, err := Parse(s)
+		if !assert.NoError(t, err, s) {
+			continue
+		}
+		again, err := Parse(rule.String())
+		if assert.NoError(t, err, rule.String()) {
+			assert.Equal(t, rule.String(), again.String())
+		}
+	}
+}
+
+// Правила, созданные без Parse и не прошедшие Validate, не дают дат.
+func TestInvalidRules(t *testing.T) {
+	after := date(t, "20240101")
+	for _, rule := range []Rule{&DayRule{}, &BusinessDayRule{}, &Shifted{}, &Shifted{Rule: &DayRule{}},
+		&WeekRule{}, &MonthRule{}, &WeekdayRule{}, &RRule{}, &CronRule{}, Union{}, Union{nil},
+		&YearRule{Interval: -1}, &WeekRule{Days: []int{8}}} {
+		require.Error(t, rule.Validate(), "%#v", rule)
+		assert.NotPanics(t, func() {
+			assert.True(t, rule.Next(after).IsZero(), "%#v", rule)
+			assert.True(t, rule.From(after).Next(after).IsZero(), "%#v", rule)
+		})
+	}
+}
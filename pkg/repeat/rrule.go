@@ -0,0 +1,547 @@
+package repeat
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+const (
+	RRulePrefix      = "RRULE:" // Префикс правила повторения в формате RFC 5545
+	MaxRRuleInterval = 400      // Максимальное значение INTERVAL в правиле RRULE
+	rruleSearchYears = 400      // Глубина поиска следующей даты по правилу RRULE в годах
+)
+
+// Значения FREQ правила RRULE.
+const (
+	FreqDaily   = "DAILY"
+	FreqWeekly  = "WEEKLY"
+	FreqMonthly = "MONTHLY"
+	FreqYearly  = "YEARLY"
+)
+
+// rruleWeekdays содержит обозначения дней недели RRULE в нумерации правил задач (пн-1...вс-7).
+var rruleWeekdays = map[string]int{"MO": 1, "TU": 2, "WE": 3, "TH": 4, "FR": 5, "SA": 6, "SU": 7}
+
+// rruleWeekdayNames содержит обозначения дней недели RRULE по их номерам (пн-1...вс-7).
+var rruleWeekdayNames = [...]string{1: "MO", 2: "TU", 3: "WE", 4: "TH", 5: "FR", 6: "SA", 7: "SU"}
+
+// WeekdayNum соответствует элементу BYDAY правила RRULE: порядковому номеру (Ordinal, 0 - каждый)
+// и дню недели (Weekday, пн-1...вс-7).
+type WeekdayNum struct {
+	Ordinal int
+	Weekday int
+}
+
+// String возвращает элемент BYDAY в формате RRULE ("MO", "2TU", "-1FR").
+func (wd WeekdayNum) String() string {
+	name := ""
+	if wd.Weekday >= 1 && wd.Weekday <= DaysInWeek {
+		name = rruleWeekdayNames[wd.Weekday]
+	}
+	if wd.Ordinal == 0 {
+		return name
+	}
+	return strconv.Itoa(wd.Ordinal) + name
+}
+
+// RRule - правило повторения в формате RFC 5545. Поддерживаются параметры FREQ, INTERVAL, BYDAY,
+// BYMONTHDAY, BYMONTH, BYYEARDAY, BYSETPOS, COUNT, UNTIL и WKST=MO. Отсутствующие параметры BYxxx
+// берутся из даты начала серии (DTSTART).
+type RRule struct {
+	Freq       string
+	Interval   int
+	ByDay      []WeekdayNum
+	ByMonthDay []int
+	ByMonth    []int
+	ByYearDay  []int
+	BySetPos   []int
+	Count      int
+	Until      time.Time
+	start      time.Time
+}
+
+// IsRRule проверяет, записано ли правило повторения s в формате RRULE.
+func IsRRule(s string) bool {
+	return len(s) >= len(RRulePrefix) && strings.EqualFold(s[:len(RRulePrefix)], RRulePrefix)
+}
+
+// parseRRule разбирает правило повторения s в формате "RRULE:FREQ=...;...". Группой в ошибке разбора
+// считается параметр правила КЛЮЧ=ЗНАЧЕНИЕ.
+func parseRRule(s string) (Rule, error) {
+	r := RRule{Interval: 1}
+	seen := make(map[string]bool)
+	offset := len(RRulePrefix)
+	for i, part := range strings.Split(strings.ToUpper(s[len(RRulePrefix):]), ";") {
+		errorAt := func(shift int, format string, args ...any) error {
+			return &ParseError{
+				Rule:  s,
+				Group: i + 1,
+				Pos:   utf8.RuneCountInString(s[:offset+shift]) + 1,
+				Msg:   fmt.Sprintf(format, args...),
+			}
+		}
+		key, value, ok := strings.Cut(part, "=")
+		if !ok || value == "" {
+			return nil, errorAt(0, "недопустимый параметр правила RRULE: '%s' (ожидается КЛЮЧ=ЗНАЧЕНИЕ)", part)
+		}
+		if seen[key] {
+			return nil, errorAt(0, "параметр '%s' указан в правиле RRULE более одного раза", key)
+		}
+		seen[key] = true
+		var err error
+		switch key {
+		case "FREQ":
+			r.Freq = value
+			err = checkFreq(value)
+		case "INTERVAL":
+			if r.Interval, err = atoi(value); err == nil {
+				err = checkRRuleInterval(r.Interval)
+			}
+		case "COUNT":
+			if r.Count, err = atoi(value); err == nil && r.Count < 1 {
+				err = fmt.Errorf("недопустимое значение COUNT: %d!(допускается от 1)", r.Count)
+			}
+		case "UNTIL":
+			r.Until, err = time.Parse(DateFormat, value[:min(len(value), len(DateFormat))])
+		case "BYDAY":
+			r.ByDay, err = parseByDay(value)
+		case "BYMONTHDAY":
+			r.ByMonthDay, err = parseRRuleList(key, value, 31, true)
+		case "BYMONTH":
+			r.ByMonth, err = parseRRuleList(key, value, 12, false)
+		case "BYYEARDAY":
+			r.ByYearDay, err = parseRRuleList(key, value, 366, true)
+		case "BYSETPOS":
+			r.BySetPos, err = parseRRuleList(key, value, 366, true)
+		case "WKST":
+			if value != "MO" {
+				err = fmt.Errorf("поддерживается только WKST=MO")
+			}
+		default:
+			return nil, errorAt(0, "неподдерживаемый параметр правила RRULE: '%s'", key)
+		}
+		if err != nil {
+			if _, ok := err.(*strconv.NumError); ok {
+				err = fmt.Errorf("недопустимое значение %s: '%s'", key, value)
+			}
+			return nil, errorAt(len(key)+1, "%s", err)
+		}
+		offset += len(part) + 1
+	}
+	if err := r.check(); err != nil {
+		return nil, &ParseError{Rule: s, Msg: err.Error()}
+	}
+	return &r, nil
+}
+
+// checkFreq проверяет значение параметра FREQ.
+func checkFreq(freq string) error {
+	if freq != FreqDaily && freq != FreqWeekly && freq != FreqMonthly && freq != FreqYearly {
+		return fmt.Errorf("недопустимое значение FREQ: '%s' (DAILY, WEEKLY, MONTHLY или YEARLY)", freq)
+	}
+	return nil
+}
+
+// checkRRuleInterval проверяет значение параметра INTERVAL.
+func checkRRuleInterval(interval int) error {
+	if interval < 1 || interval > MaxRRuleInterval {
+		return fmt.Errorf("недопустимое значение INTERVAL: %d!(допускается от 1 до %d)", interval, MaxRRuleInterval)
+	}
+	return nil
+}
+
+// checkRRuleList проверяет список чисел values параметра key правила RRULE. Допускаются значения
+// от 1 до max, а при negative - также от -max до -1.
+func checkRRuleList(key string, values []int, max int, negative bool) error {
+	for _, v := range values {
+		if (v < 1 || v > max) && (!negative || v > -1 || v < -max) {
+			return fmt.Errorf("недопустимое значение %s: %d", key, v)
+		}
+	}
+	return nil
+}
+
+// parseByDay разбирает значение параметра BYDAY вида "MO,2TU,-1FR".
+func parseByDay(value string) ([]WeekdayNum, error) {
+	var list []WeekdayNum
+	for _, v := range strings.Split(value, ",") {
+		if len(v) < 2 {
+			return nil, fmt.Errorf("недопустимое значение BYDAY: '%s'", v)
+		}
+		weekday, ok := rruleWeekdays[v[len(v)-2:]]
+		if !ok {
+			return nil, fmt.Errorf("недопустимый день недели в BYDAY: '%s' (MO, TU, WE, TH, FR, SA или SU)", v)
+		}
+		var ordinal int
+		if len(v) > 2 {
+			var err error
+			ordinal, err = strconv.Atoi(v[:len(v)-2])
+			if err != nil || ordinal == 0 {
+				return nil, fmt.Errorf("недопустимый порядковый номер в BYDAY: '%s'", v)
+			}
+		}
+		list = append(list, WeekdayNum{Ordinal: ordinal, Weekday: weekday})
+	}
+	return list, nil
+}
+
+// parseRRuleList разбирает список чисел value параметра key правила RRULE и проверяет его checkRRuleList.
+func parseRRuleList(key, value string, max int, negative bool) ([]int, error) {
+	var list []int
+	for _, item := range strings.Split(value, ",") {
+		v, err := atoi(item)
+		if err != nil {
+			return nil, fmt.Errorf("недопустимое значение %s: '%s'", key, value)
+		}
+		list = append(list, v)
+	}
+	return list, checkRRuleList(key, list, max, negative)
+}
+
+// Validate проверяет допустимость значений параметров правила и их совместимость между собой.
+func (r *RRule) Validate() error {
+	if err := checkFreq(r.Freq); err != nil {
+		return err
+	}
+	if err := checkRRuleInterval(intervalOrOne(r.Interval)); err != nil {
+		return err
+	}
+	if r.Count < 0 {
+		return fmt.Errorf("недопустимое значение COUNT: %d!(допускается от 1)", r.Count)
+	}
+	for _, d := range r.ByDay {
+		if err := checkWeekday(d.Weekday); err != nil {
+			return err
+		}
+	}
+	if err := checkRRuleList("BYMONTHDAY", r.ByMonthDay, 31, true); err != nil {
+		return err
+	}
+	if err := checkRRuleList("BYMONTH", r.ByMonth, 12, false); err != nil {
+		return err
+	}
+	if err := checkRRuleList("BYYEARDAY", r.ByYearDay, 366, true); err != nil {
+		return err
+	}
+	if err := checkRRuleList("BYSETPOS", r.BySetPos, 366, true); err != nil {
+		return err
+	}
+	return r.check()
+}
+
+// check проверяет совместимость параметров правила RRULE между собой.
+func (r *RRule) check() error {
+	if r.Freq == "" {
+		return fmt.Errorf("в правиле RRULE отсутствует обязательный параметр FREQ")
+	}
+	if r.Count > 0 && !r.Until.IsZero() {
+		return fmt.Errorf("параметры COUNT и UNTIL не могут быть указаны одновременно")
+	}
+	if r.Freq == FreqWeekly && len(r.ByMonthDay) > 0 {
+		return fmt.Errorf("параметр BYMONTHDAY недопустим при FREQ=WEEKLY")
+	}
+	if r.Freq != FreqYearly && len(r.ByYearDay) > 0 {
+		return fmt.Errorf("параметр BYYEARDAY допустим только при FREQ=YEARLY")
+	}
+	for _, d := range r.ByDay {
+		if d.Ordinal != 0 && r.Freq != FreqMonthly && r.Freq != FreqYearly {
+			return fmt.Errorf("порядковый номер в BYDAY допустим только при FREQ=MONTHLY или FREQ=YEARLY")
+		}
+		if d.Ordinal != 0 && r.Freq == FreqYearly && len(r.ByMonth) == 0 && (d.Ordinal > 53 || d.Ordinal < -53) {
+			return fmt.Errorf("недопустимый порядковый номер в BYDAY: %d!(допускается от -53 до 53)", d.Ordinal)
+		}
+		if d.Ordinal != 0 && (r.Freq == FreqMonthly || len(r.ByMonth) > 0) && (d.Ordinal > 5 || d.Ordinal < -5) {
+			return fmt.Errorf("недопустимый порядковый номер в BYDAY: %d!(допускается от -5 до 5)", d.Ordinal)
+		}
+	}
+	if len(r.BySetPos) > 0 && len(r.ByDay)+len(r.ByMonthDay)+len(r.ByMonth)+len(r.ByYearDay) == 0 {
+		return fmt.Errorf("параметр BYSETPOS допустим только вместе с другими параметрами BYxxx")
+	}
+	return nil
+}
+
+// String возвращает правило в каноническом виде: параметры в фиксированном порядке, INTERVAL=1 опущен.
+func (r *RRule) String() string {
+	parts := []string{"FREQ=" + r.Freq}
+	if intervalOrOne(r.Interval) != 1 {
+		parts = append(parts, "INTERVAL="+strconv.Itoa(r.Interval))
+	}
+	if len(r.ByDay) > 0 {
+		var days []string
+		for _, d := range r.ByDay {
+			days = append(days, d.String())
+		}
+		parts = append(parts, "BYDAY="+strings.Join(days, ","))
+	}
+	lists := []struct {
+		key    string
+		values []int
+	}{
+		{"BYMONTHDAY", r.ByMonthDay},
+		{"BYMONTH", r.ByMonth},
+		{"BYYEARDAY", r.ByYearDay},
+		{"BYSETPOS", r.BySetPos},
+	}
+	for _, l := range lists {
+		if len(l.values) > 0 {
+			parts = append(parts, l.key+"="+formatList(l.values))
+		}
+	}
+	if r.Count > 0 {
+		parts = append(parts, "COUNT="+strconv.Itoa(r.Count))
+	}
+	if !r.Until.IsZero() {
+		parts = append(parts, "UNTIL="+r.Until.Format(DateFormat))
+	}
+	return RRulePrefix + strings.Join(parts, ";")
+}
+
+// From возвращает копию правила с датой начала серии (DTSTART) start.
+func (r *RRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
+
+// Next возвращает первую дату серии строго после after. Если серия исчерпана (COUNT или UNTIL) или
+// правило не даёт дат, возвращает нулевое время.
+func (r *RRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	dstart, after := window(r.start, after)
+	interval := intervalOrOne(r.Interval)
+	period := r.periodStart(dstart)
+	if r.Count == 0 {
+		// Без COUNT предшествующие периоды не влияют на результат, поэтому их можно пропустить.
+		skip := r.periodsBetween(period, r.periodStart(after)) / interval * interval
+		period = r.advance(period, skip)
+	}
+	limit := r.advance(after, interval).AddDate(rruleSearchYears, 0, 0)
+	var n int
+	for ; !period.After(limit); period = r.advance(period, interval) {
+		for _, d := range r.expand(period, dstart) {
+			if d.Before(dstart) {
+				continue
+			}
+			n++
+			if (r.Count > 0 && n > r.Count) || (!r.Until.IsZero() && d.After(r.Until)) {
+				return time.Time{}
+			}
+			if d.After(after) {
+				return d
+			}
+		}
+	}
+	return time.Time{}
+}
+
+// periodStart возвращает начало периода FREQ, в который попадает дата t.
+func (r *RRule) periodStart(t time.Time) time.Time {
+	switch r.Freq {
+	case FreqWeekly:
+		return startOfWeek(t)
+	case FreqMonthly:
+		return startOfMonth(t)
+	case FreqYearly:
+		return time.Date(t.Year(), time.January, 1, 0, 0, 0, 0, t.Location())
+	}
+	return t
+}
+
+// periodsBetween возвращает количество периодов FREQ между началами периодов from и to.
+func (r *RRule) periodsBetween(from, to time.Time) int {
+	switch r.Freq {
+	case FreqWeekly:
+		return daysBetween(from, to) / DaysInWeek
+	case FreqMonthly:
+		return monthsBetween(from, to)
+	case FreqYearly:
+		return to.Year() - from.Year()
+	}
+	return daysBetween(from, to)
+}
+
+// advance возвращает начало периода FREQ, отстоящего от period на n периодов.
+func (r *RRule) advance(period time.Time, n int) time.Time {
+	switch r.Freq {
+	case FreqWeekly:
+		return period.AddDate(0, 0, n*DaysInWeek)
+	case FreqMonthly:
+		return period.AddDate(0, n, 0)
+	case FreqYearly:
+		return period.AddDate(n, 0, 0)
+	}
+	return period.AddDate(0, 0, n)
+}
+
+// expand возвращает упорядоченный список дат серии внутри периода, начинающегося с period.
+// Дата начала серии (dstart) задаёт значения по умолчанию для отсутствующих параметров BYxxx.
+func (r *RRule) expand(period, dstart time.Time) []time.Time {
+	var dates []time.Time
+	switch r.Freq {
+	case FreqDaily:
+		if r.matchMonth(period) && r.matchMonthDay(period) && r.matchWeekday(period) {
+			dates = append(dates, period)
+		}
+	case FreqWeekly:
+		for i := 0; i < DaysInWeek; i++ {
+			d := period.AddDate(0, 0, i)
+			if len(r.ByDay) == 0 && Weekday(d) != Weekday(dstart) {
+				continue
+			}
+			if r.matchMonth(d) && r.matchWeekday(d) {
+				dates = append(dates, d)
+			}
+		}
+	case FreqMonthly:
+		if r.matchMonth(period) {
+			dates = r.expandMonth(period, dstart)
+		}
+	case FreqYearly:
+		dates = r.expandYear(period, dstart)
+	}
+	return r.applySetPos(dates)
+}
+
+// expandMonth возвращает даты серии внутри месяца, первое число которого передано в month.
+func (r *RRule) expandMonth(month, dstart time.Time) []time.Time {
+	var dates []time.Time
+	switch {
+	case len(r.ByDay) > 0:
+		for _, d := range r.byDayIn(month, month.AddDate(0, 1, -1)) {
+			if r.matchMonthDay(d) {
+				dates = append(dates, d)
+			}
+		}
+	case len(r.ByMonthDay) > 0:
+		for d := month; d.Month() == month.Month(); d = d.AddDate(0, 0, 1) {
+			if r.matchMonthDay(d) {
+				dates = append(dates, d)
+			}
+		}
+	default:
+		d := month.AddDate(0, 0, dstart.Day()-1)
+		if d.Month() == month.Month() {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// expandYear возвращает даты серии внутри года, 1 января которого передано в year.
+func (r *RRule) expandYear(year, dstart time.Time) []time.Time {
+	var dates []time.Time
+	switch {
+	case len(r.ByYearDay) > 0:
+		end := year.AddDate(1, 0, -1)
+		for d := year; !d.After(end); d = d.AddDate(0, 0, 1) {
+			if r.matchYearDay(d) && r.matchMonth(d) && r.matchMonthDay(d) && r.matchWeekday(d) {
+				dates = append(dates, d)
+			}
+		}
+	case len(r.ByDay) > 0 && len(r.ByMonth) == 0 && len(r.ByMonthDay) == 0:
+		dates = r.byDayIn(year, year.AddDate(1, 0, -1))
+	case len(r.ByMonth) > 0 || len(r.ByMonthDay) > 0 || len(r.ByDay) > 0:
+		for m := year; m.Year() == year.Year(); m = m.AddDate(0, 1, 0) {
+			if len(r.ByMonth) == 0 || r.matchMonth(m) {
+				dates = append(dates, r.expandMonth(m, dstart)...)
+			}
+		}
+	default:
+		d := time.Date(year.Year(), dstart.Month(), dstart.Day(), 0, 0, 0, 0, year.Location())
+		if d.Day() == dstart.Day() {
+			dates = append(dates, d)
+		}
+	}
+	return dates
+}
+
+// byDayIn возвращает упорядоченные даты от from до to включительно, соответствующие параметру BYDAY.
+// Порядковые номера отсчитываются внутри этого промежутка.
+func (r *RRule) byDayIn(from, to time.Time) []time.Time {
+	var dates []time.Time
+	for _, wd := range r.ByDay {
+		var all []time.Time
+		first := from.AddDate(0, 0, (wd.Weekday-Weekday(from)+DaysInWeek)%DaysInWeek)
+		for d := first; !d.After(to); d = d.AddDate(0, 0, DaysInWeek) {
+			all = append(all, d)
+		}
+		switch {
+		case wd.Ordinal == 0:
+			dates = append(dates, all...)
+		case wd.Ordinal > 0 && wd.Ordinal <= len(all):
+			dates = append(dates, all[wd.Ordinal-1])
+		case wd.Ordinal < 0 && -wd.Ordinal <= len(all):
+			dates = append(dates, all[len(all)+wd.Ordinal])
+		}
+	}
+	return sortDates(dates)
+}
+
+// applySetPos оставляет из упорядоченного списка дат периода только позиции, указанные в BYSETPOS.
+func (r *RRule) applySetPos(dates []time.Time) []time.Time {
+	dates = sortDates(dates)
+	if len(r.BySetPos) == 0 {
+		return dates
+	}
+	var res []time.Time
+	for _, p := range r.BySetPos {
+		switch {
+		case p > 0 && p <= len(dates):
+			res = append(res, dates[p-1])
+		case p < 0 && -p <= len(dates):
+			res = append(res, dates[len(dates)+p])
+		}
+	}
+	return sortDates(res)
+}
+
+// matchMonth проверяет соответствие даты t параметру BYMONTH.
+func (r *RRule) matchMonth(t time.Time) bool {
+	return len(r.ByMonth) == 0 || contains(r.ByMonth, int(t.Month()))
+}
+
+// matchMonthDay проверяет соответствие даты t параметру BYMONTHDAY.
+func (r *RRule) matchMonthDay(t time.Time) bool {
+	if len(r.ByMonthDay) == 0 {
+		return true
+	}
+	last := daysIn(t)
+	return contains(r.ByMonthDay, t.Day()) || contains(r.ByMonthDay, t.Day()-last-1)
+}
+
+// matchYearDay проверяет соответствие даты t параметру BYYEARDAY.
+func (r *RRule) matchYearDay(t time.Time) bool {
+	last := time.Date(t.Year(), time.December, 31, 0, 0, 0, 0, t.Location()).YearDay()
+	return contains(r.ByYearDay, t.YearDay()) || contains(r.ByYearDay, t.YearDay()-last-1)
+}
+
+// matchWeekday проверяет соответствие дня недели даты t параметру BYDAY без учёта порядковых номеров.
+func (r *RRule) matchWeekday(t time.Time) bool {
+	if len(r.ByDay) == 0 {
+		return true
+	}
+	for _, wd := range r.ByDay {
+		if wd.Weekday == Weekday(t) {
+			return true
+		}
+	}
+	return false
+}
+
+// sortDates упорядочивает даты по возрастанию и удаляет повторы.
+func sortDates(dates []time.Time) []time.Time {
+	sort.Slice(dates, func(i, j int) bool { return dates[i].Before(dates[j]) })
+	var res []time.Time
+	for i, d := range dates {
+		if i == 0 || !d.Equal(dates[i-1]) {
+			res = append(res, d)
+		}
+	}
+	return res
+}
@@ -0,0 +1,122 @@
+package repeat
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// rruleParam соответствует параметру правила RRULE вида "КЛЮЧ=ЗНАЧЕНИЕ".
+var rruleParam = regexp.MustCompile(`^[A-Za-z]+=`)
+
+// Union - объединение правил "d 7; w 7": даты объединения - это даты всех входящих в него правил.
+// Следующей датой объединения считается самая ранняя из следующих дат его правил.
+type Union []Rule
+
+// unionPart соответствует правилу в строке объединения и его смещению в байтах от начала строки.
+type unionPart struct {
+	text   string
+	offset int
+}
+
+// splitUnion разбивает строку s на правила по разделителю UnionSeparator. Так как параметры правила
+// RRULE разделяются тем же символом, части вида "КЛЮЧ=ЗНАЧЕНИЕ", следующие за правилом RRULE,
+// относятся к нему.
+func splitUnion(s string) []unionPart {
+	var parts []unionPart
+	offset := 0
+	for _, seg := range strings.Split(s, UnionSeparator) {
+		trimmed := strings.TrimSpace(seg)
+		n := len(parts)
+		if n > 0 && IsRRule(parts[n-1].text) && rruleParam.MatchString(trimmed) {
+			parts[n-1].text += UnionSeparator + trimmed
+		} else {
+			parts = append(parts, unionPart{text: trimmed, offset: offset + strings.Index(seg, trimmed)})
+		}
+		offset += len(seg) + len(UnionSeparator)
+	}
+	return parts
+}
+
+// parseUnion разбирает объединение правил s, разбитое на части parts. Ошибка разбора указывает номер
+// неверного правила в объединении и позицию в исходной строке.
+func parseUnion(s string, parts []unionPart) (Rule, error) {
+	var union Union
+	for i, part := range parts {
+		if len(part.text) == 0 {
+			return nil, &ParseError{Rule: s, Part: i + 1, Msg: "пустое правило в объединении"}
+		}
+		rule, err := parseSingle(part.text)
+		var perr *ParseError
+		if errors.As(err, &perr) {
+			pos := perr.Pos
+			if pos > 0 {
+				pos += utf8.RuneCountInString(s[:part.offset])
+			}
+			return nil, &ParseError{Rule: s, Part: i + 1, Group: perr.Group, Pos: pos, Msg: perr.Msg}
+		}
+		if err != nil {
+			return nil, err
+		}
+		union = append(union, rule)
+	}
+	return union, nil
+}
+
+// Next возвращает самую раннюю из дат правил объединения строго после after. Если ни одно из правил
+// больше не даёт дат, возвращает нулевое время.
+func (u Union) Next(after time.Time) time.Time {
+	if u.Validate() != nil {
+		return time.Time{}
+	}
+	var next time.Time
+	for _, rule := range u {
+		d := rule.Next(after)
+		if !d.IsZero() && (next.IsZero() || d.Before(next)) {
+			next = d
+		}
+	}
+	return next
+}
+
+// String возвращает объединение в каноническом виде: правила в каноническом виде через "; ".
+func (u Union) String() string {
+	var parts []string
+	for _, rule := range u {
+		parts = append(parts, rule.String())
+	}
+	return strings.Join(parts, UnionSeparator+" ")
+}
+
+// Validate проверяет допустимость значений всех правил объединения.
+func (u Union) Validate() error {
+	if len(u) == 0 {
+		return fmt.Errorf("объединение не содержит правил")
+	}
+	for i, rule := range u {
+		if rule == nil {
+			return fmt.Errorf("правило %d объединения не указано", i+1)
+		}
+		if _, ok := rule.(Union); ok {
+			return fmt.Errorf("правило %d объединения: вложенные объединения недопустимы", i+1)
+		}
+		if err := rule.Validate(); err != nil {
+			return fmt.Errorf("правило %d объединения: %w", i+1, err)
+		}
+	}
+	return nil
+}
+
+// From возвращает копию объединения, правила которого имеют дату начала серии start.
+func (u Union) From(start time.Time) Rule {
+	c := make(Union, len(u))
+	for i, rule := range u {
+		if rule != nil {
+			c[i] = rule.From(start)
+		}
+	}
+	return c
+}
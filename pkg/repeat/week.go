@@ -0,0 +1,52 @@
+package repeat
+
+import (
+	"time"
+)
+
+// WeekRule - правило недели "w <дни недели> [/N]": задача повторяется в указанные дни недели (пн-1...вс-7)
+// каждой Interval-й недели, отсчитываемой от недели начала серии.
+type WeekRule struct {
+	Days     []int
+	Interval int
+	start    time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *WeekRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	interval := intervalOrOne(r.Interval)
+	next := after.AddDate(0, 0, 1)
+	// Каждый день недели встречается хотя бы раз за interval недель.
+	for i := 0; i <= DaysInWeek*interval; i++ {
+		weeks := daysBetween(startOfWeek(start), startOfWeek(next)) / DaysInWeek
+		if weeks%interval == 0 && contains(r.Days, Weekday(next)) {
+			return next
+		}
+		next = next.AddDate(0, 0, 1)
+	}
+	return time.Time{}
+}
+
+// String возвращает правило в каноническом виде.
+func (r *WeekRule) String() string {
+	return WeekLetter + " " + formatList(r.Days) + formatInterval(r.Interval)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *WeekRule) Validate() error {
+	if err := checkList("дни недели", r.Days, checkWeekday); err != nil {
+		return err
+	}
+	return checkIntervalOrZero(r.Interval)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *WeekRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
@@ -0,0 +1,47 @@
+package repeat
+
+import (
+	"time"
+)
+
+// YearRule - правило года "y [/N]": задача повторяется каждые Interval лет в день и месяц начала серии.
+// Годы отсчитываются от даты начала серии, поэтому 29 февраля в невисокосный год переносится на 1 марта,
+// а в високосный год остаётся 29 февраля.
+type YearRule struct {
+	Interval int
+	start    time.Time
+}
+
+// Next возвращает первую дату правила строго после after.
+func (r *YearRule) Next(after time.Time) time.Time {
+	if r.Validate() != nil {
+		return time.Time{}
+	}
+	start, after := window(r.start, after)
+	interval := intervalOrOne(r.Interval)
+	// Пропуск целых интервалов, заведомо не попадающих в промежуток до after.
+	k := max(1, (after.Year()-start.Year())/interval)
+	next := start.AddDate(k*interval, 0, 0)
+	for !next.After(after) {
+		k++
+		next = start.AddDate(k*interval, 0, 0)
+	}
+	return next
+}
+
+// String возвращает правило в каноническом виде.
+func (r *YearRule) String() string {
+	return YearLetter + formatInterval(r.Interval)
+}
+
+// Validate проверяет допустимость значений правила.
+func (r *YearRule) Validate() error {
+	return checkIntervalOrZero(r.Interval)
+}
+
+// From возвращает копию правила с датой начала серии start.
+func (r *YearRule) From(start time.Time) Rule {
+	c := *r
+	c.start = midnight(start)
+	return &c
+}
@@ -0,0 +1,59 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go1f/pkg/db"
+)
+
+// DefaultBackupHours содержит период автоматического резервного копирования в часах по умолчанию.
+var DefaultBackupHours = 24
+
+// DefaultBackupKeep содержит количество хранимых резервных копий по умолчанию.
+var DefaultBackupKeep = 7
+
+var envBackupDir = os.Getenv("TODO_BACKUP_DIR") // Получаем переменную окружения TODO_BACKUP_DIR.
+
+var envBackupHours = os.Getenv("TODO_BACKUP_HOURS") // Получаем переменную окружения TODO_BACKUP_HOURS.
+
+var envBackupKeep = os.Getenv("TODO_BACKUP_KEEP") // Получаем переменную окружения TODO_BACKUP_KEEP.
+
+// getBackupSchedule возвращает период резервного копирования из переменной среды окружения
+// TODO_BACKUP_HOURS (в часах, по умолчанию DefaultBackupHours) и количество хранимых копий из
+// TODO_BACKUP_KEEP (по умолчанию DefaultBackupKeep, 0 - копии не удаляются).
+func getBackupSchedule() (time.Duration, int, error) {
+	hours, keep := DefaultBackupHours, DefaultBackupKeep
+	if len(envBackupHours) > 0 {
+		n, err := strconv.Atoi(envBackupHours)
+		if err != nil || n <= 0 {
+			return 0, 0, fmt.Errorf("недопустимый период резервного копирования TODO_BACKUP_HOURS='%s'",
+				envBackupHours)
+		}
+		hours = n
+	}
+	if len(envBackupKeep) > 0 {
+		n, err := strconv.Atoi(envBackupKeep)
+		if err != nil || n < 0 {
+			return 0, 0, fmt.Errorf("недопустимое количество резервных копий TODO_BACKUP_KEEP='%s'", envBackupKeep)
+		}
+		keep = n
+	}
+	return time.Duration(hours) * time.Hour, keep, nil
+}
+
+// backupDatabase каждые interval записывает резервную копию базы данных в каталог dir, оставляя keep
+// последних копий. Первая копия создаётся через interval после запуска. Ошибки выводятся и не прерывают
+// работу.
+func backupDatabase(dir string, interval time.Duration, keep int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for range ticker.C {
+		if _, err := db.BackupToDir(context.Background(), dir, keep); err != nil {
+			fmt.Printf("Ошибка резервного копирования БД: %s\n", err.Error())
+		}
+	}
+}
@@ -27,9 +27,26 @@ func getPort() int {
 	return port
 }
 
-// RunServer запускает сервер.
+// RunServer запускает сервер, фоновую очистку корзины от задач с истёкшим сроком хранения и, если задана
+// переменная среды окружения TODO_BACKUP_DIR, периодическое резервное копирование базы данных в этот каталог.
 func RunServer() error {
-	api.Init()
+	if err := api.Init(); err != nil {
+		return err
+	}
+	retention, err := getTrashRetention()
+	if err != nil {
+		return err
+	}
+	if retention > 0 {
+		go purgeTrash(retention)
+	}
+	if len(envBackupDir) > 0 {
+		interval, keep, err := getBackupSchedule()
+		if err != nil {
+			return err
+		}
+		go backupDatabase(envBackupDir, interval, keep)
+	}
 
 	port := getPort()
 	fmt.Printf("Приложение запущено на порту: %d", port)
@@ -0,0 +1,47 @@
+package server
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"go1f/pkg/db"
+)
+
+// DefaultTrashDays содержит срок хранения задач в корзине в днях по умолчанию.
+var DefaultTrashDays = 30
+
+// trashInterval содержит период проверки корзины на задачи с истёкшим сроком хранения.
+var trashInterval = time.Hour
+
+var envTrashDays = os.Getenv("TODO_TRASH_DAYS") // Получаем переменную окружения TODO_TRASH_DAYS.
+
+// getTrashRetention возвращает срок хранения задач в корзине из переменной среды окружения TODO_TRASH_DAYS
+// (в днях). Если таковая отсутствует, возвращает значение DefaultTrashDays. Значение 0 отключает
+// автоматическую очистку корзины.
+func getTrashRetention() (time.Duration, error) {
+	days := DefaultTrashDays
+	if len(envTrashDays) > 0 {
+		n, err := strconv.Atoi(envTrashDays)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("недопустимый срок хранения задач в корзине TODO_TRASH_DAYS='%s'", envTrashDays)
+		}
+		days = n
+	}
+	return time.Duration(days) * 24 * time.Hour, nil
+}
+
+// purgeTrash каждые trashInterval окончательно удаляет задачи, находящиеся в корзине дольше retention.
+// Первая очистка выполняется сразу. Ошибки выводятся и не прерывают работу.
+func purgeTrash(retention time.Duration) {
+	ticker := time.NewTicker(trashInterval)
+	defer ticker.Stop()
+	for {
+		if _, err := db.PurgeTrash(context.Background(), time.Now().Add(-retention)); err != nil {
+			fmt.Printf("Ошибка очистки корзины: %s\n", err.Error())
+		}
+		<-ticker.C
+	}
+}
@@ -108,6 +108,16 @@ func TestAddTask(t *testing.T) {
 
 	now := time.Now()
 
+	for _, date := range []string{now.Format(`20060102`), now.AddDate(0, 0, 3).Format(`20060102`)} {
+		m, err := postJSON("api/task", map[string]any{
+			"date":   date,
+			"title":  "Заголовок",
+			"repeat": "k 1",
+		}, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, m["error"], "Ожидается ошибка для правила 'k 1' и даты %s", date)
+	}
+
 	check := func() {
 		for _, v := range tbl {
 			today := v.date == "today"
@@ -165,6 +175,7 @@ func TestAddTask(t *testing.T) {
 	if FullNextDate {
 		tbl = []task{
 			{"20240129", "Сходить в магазин", "", "w 1,3,5"},
+			{"20240129", "Подготовить отчёт", "", "n -1 5"},
 		}
 		check()
 	}
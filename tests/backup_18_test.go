@@ -0,0 +1,47 @@
+package tests
+
+import (
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBackup(t *testing.T) {
+	if len(Token) == 0 {
+		resp, m := requestIfMatch(t, "api/backup", nil, http.MethodGet, "")
+		if resp != nil {
+			assert.Equal(t, http.StatusForbidden, resp.StatusCode)
+			assert.NotEmpty(t, m["error"])
+		}
+		return
+	}
+
+	db := openDB(t)
+	defer db.Close()
+
+	var count int
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM scheduler`))
+
+	body, err := requestJSON("api/backup", nil, http.MethodGet)
+	assert.NoError(t, err)
+	if !assert.Greater(t, len(body), 16) {
+		return
+	}
+	assert.Equal(t, "SQLite format 3\x00", string(body[:16]))
+
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	assert.NoError(t, os.WriteFile(path, body, 0o644))
+	snapshot, err := sqlx.Connect("sqlite", path)
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer snapshot.Close()
+
+	var copied int
+	assert.NoError(t, snapshot.Get(&copied, `SELECT count(*) FROM scheduler`))
+	assert.Equal(t, count, copied)
+}
@@ -11,11 +11,19 @@ import (
 )
 
 type Task struct {
-	ID      int64  `db:"id"`
-	Date    string `db:"date"`
-	Title   string `db:"title"`
-	Comment string `db:"comment"`
-	Repeat  string `db:"repeat"`
+	ID        int64  `db:"id"`
+	Date      string `db:"date"`
+	Title     string `db:"title"`
+	Comment   string `db:"comment"`
+	Repeat    string `db:"repeat"`
+	Until     string `db:"until"`
+	Remaining int    `db:"remaining"`
+	AfterDone bool   `db:"after_done"`
+	Time      string `db:"time"`
+	TZ        string `db:"tz"`
+	CreatedAt string `db:"created_at"`
+	DeletedAt string `db:"deleted_at"`
+	Version   int64  `db:"version"`
 }
 
 func count(db *sqlx.DB) (int, error) {
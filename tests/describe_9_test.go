@@ -0,0 +1,56 @@
+package tests
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDescribe(t *testing.T) {
+	tbl := []struct {
+		repeat string
+		lang   string
+		want   string
+	}{
+		{"m 1,-1 2,8", "", "каждый первый и последний день февраля и августа"},
+		{"m 1,-1 2,8", "en", "on the 1st and last day of February and August"},
+		{"d 3", "ru", "каждые 3 дня"},
+		{"w 1,5 /2", "en", "every Monday and Friday, every 2 weeks"},
+		{"n -1 5", "ru", "в последнюю пятницу месяца"},
+		{"y", "en", "every year"},
+		{"RRULE:FREQ=DAILY;COUNT=5", "ru", "каждый день, всего 5 раз"},
+	}
+	for _, v := range tbl {
+		body, err := getBody("api/describe?repeat=" + url.QueryEscape(v.repeat) + "&lang=" + v.lang)
+		assert.NoError(t, err)
+		assert.Equal(t, v.want, string(body), "Правило %q", v.repeat)
+	}
+	body, err := getBody("api/describe?repeat=k+1")
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), "группа 1")
+
+	db := openDB(t)
+	defer db.Close()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":   time.Now().Format(`20060102`),
+		"title":  "Заменить фильтр",
+		"repeat": "d 30",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	body, err = requestJSON("api/task?id="+id+"&lang=en", nil, http.MethodGet)
+	assert.NoError(t, err)
+	var m map[string]any
+	assert.NoError(t, json.Unmarshal(body, &m))
+	assert.Equal(t, "every 30 days", m["repeat_text"])
+
+	_, err = requestJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+}
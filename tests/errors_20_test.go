@@ -0,0 +1,28 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestErrorResponse(t *testing.T) {
+	for _, v := range []struct {
+		path   string
+		method string
+		values map[string]any
+	}{
+		{"api/task?id=999999999", http.MethodGet, nil},
+		{"api/task", http.MethodPost, map[string]any{"title": "Заголовок", "date": "2024.01.01"}},
+		{"api/tasks?cursor=ooops", http.MethodGet, nil},
+	} {
+		resp, ret := requestIfMatch(t, v.path, v.values, v.method, "")
+		if resp == nil {
+			continue
+		}
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode, "%s %s", v.method, v.path)
+		assert.Equal(t, "application/json; charset=UTF-8", resp.Header.Get("Content-Type"), "%s %s", v.method, v.path)
+		assert.NotEmpty(t, ret["error"])
+	}
+}
@@ -0,0 +1,77 @@
+package tests
+
+import (
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompletions(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM task_completions")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	history := func(path string) []map[string]any {
+		ret, err := postJSON(path, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"], path)
+		list, _ := ret["completions"].([]any)
+		var res []map[string]any
+		for _, v := range list {
+			c, _ := v.(map[string]any)
+			res = append(res, c)
+		}
+		return res
+	}
+
+	id := addTask(t, task{date: now.Format(`20060102`), title: "Зарядка", repeat: "d 1"})
+	ret, err := postJSON("api/task/done?id="+id, map[string]any{"note": "20 минут"}, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	list := history("api/task/history?id=" + id)
+	if assert.Len(t, list, 2) {
+		assert.Equal(t, id, list[0]["task_id"])
+		assert.Equal(t, "Зарядка", list[0]["title"])
+		assert.Equal(t, now.Format(`20060102`), list[0]["date"])
+		assert.Equal(t, "20 минут", list[0]["note"])
+		assert.NotEmpty(t, list[0]["done_at"])
+		assert.Equal(t, now.AddDate(0, 0, 1).Format(`20060102`), list[1]["date"])
+		assert.Nil(t, list[1]["note"])
+	}
+
+	once := addTask(t, task{date: now.Format(`20060102`), title: "Купить билеты"})
+	ret, err = postJSON("api/task/done?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, once)
+	assert.Len(t, history("api/task/history?id="+once), 1)
+
+	assert.Len(t, history("api/completions"), 3)
+	assert.Len(t, history("api/completions?from=today&to=today"), 3)
+	assert.Empty(t, history("api/completions?from=tomorrow"))
+	assert.Empty(t, history("api/completions?to=yesterday"))
+
+	var count int
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM task_completions`))
+	assert.Equal(t, 3, count)
+	ret, err = postJSON("api/task/done?id="+once, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM task_completions`))
+	assert.Equal(t, 3, count, "выполнение задачи в корзине не записывается")
+
+	for _, path := range []string{"api/task/history", "api/completions?from=32.01.2026", "api/completions?tz=Mars/Olympus"} {
+		ret, err := postJSON(path, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для %s", path)
+	}
+}
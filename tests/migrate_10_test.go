@@ -0,0 +1,25 @@
+package tests
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMigrations(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	var versions []int
+	err := db.Select(&versions, `SELECT version FROM schema_version ORDER BY version`)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, versions)
+	for i, v := range versions {
+		assert.Equal(t, i+1, v, "миграции должны применяться по порядку")
+	}
+
+	var count int
+	err = db.Get(&count, `SELECT count(*) FROM pragma_table_info('scheduler') WHERE name IN ('until', 'remaining', 'after_done', 'time', 'tz')`)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, count)
+}
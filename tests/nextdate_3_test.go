@@ -1,6 +1,7 @@
 package tests
 
 import (
+	"encoding/json"
 	"fmt"
 	"net/url"
 	"strings"
@@ -74,6 +75,82 @@ func TestNextDate(t *testing.T) {
 		{"20240126", "w 7", "20240128"},
 		{"20230126", "w 4,5", "20240201"},
 		{"20230226", "w 8,4,5", ""},
+		{"20240108", "w 1,3 /2", "20240205"},
+		{"20240101", "m 1 /3", "20240401"},
+		{"20240101", "m 1 2 /12", ""},
+		{"20240101", "m 1 /0", ""},
+		{"20230315", "y /2", "20250315"},
+		{"20240229", "y /4", "20280229"},
+		{"20240126", "d 7 /2", ""},
+		{"20240101", "n -1 5", "20240223"},
+		{"20240101", "n 2 2", "20240213"},
+		{"20240101", "n 1 1 1,4,7,10", "20240401"},
+		{"20240101", "n 1 1 1 /2", "20250106"},
+		{"20240101", "n 6 1", ""},
+		{"20240101", "n 1", ""},
+		{"20240101", "RRULE:FREQ=MONTHLY;BYDAY=MO,TU,WE,TH,FR;BYSETPOS=-1", "20240131"},
+		{"20240101", "RRULE:FREQ=YEARLY;BYMONTH=1;BYDAY=-1FR", "20250131"},
+		{"20240101", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE", "20240129"},
+		{"20240101", "RRULE:FREQ=WEEKLY;INTERVAL=2;BYDAY=MO,WE;COUNT=3", ""},
+		{"20240101", "RRULE:FREQ=DAILY;UNTIL=20240130", "20240127"},
+		{"20240101", "RRULE:FREQ=DAILY;BYMONTHDAY=30;BYMONTH=2", ""},
+		{"20240101", "RRULE:FREQ=DAILY;BYHOUR=10", ""},
+		{"20240126", "b 1", "20240129"},
+		{"20240122", "b 3", "20240130"},
+		{"20240126", "b 0", ""},
+		{"20240101", "m 3 >", "20240205"},
+		{"20240101", "m 28 >", "20240129"},
+		{"20240101", "w 1 >", ""},
+		{"20230101", "y >", "20250101"},
+		{"20240126", "0 9 * * 1-5", "20240129"},
+		{"20240126", "30 8 13 * 5", "20240202"},
+		{"20240101", "@monthly", "20240201"},
+		{"20240101", "@weekly", "20240128"},
+		{"20240101", "0 0 1 jan,JUL *", "20240701"},
+		{"20240101", "0 0 */10 * *", "20240131"},
+		{"20240101", "0 0 * *", ""},
+		{"20240101", "0 0 32 * *", ""},
+		{"20240101", "@hourly", ""},
+		{"20240101", "m 1; w 7", "20240128"},
+		{"20240126", "d 10; RRULE:FREQ=WEEKLY;BYDAY=MO", "20240129"},
+		{"20240101", "RRULE:FREQ=DAILY;COUNT=3; m 5", "20240205"},
+		{"20240101", "d 1;", ""},
+		{"20240101", "w 1; m 32", ""},
 	}
 	check()
+	// Исключённые даты пропускаются.
+	get, err := getBody("api/nextdate?now=20240126&date=20240126&repeat=w+5&except=20240202,20240209")
+	assert.NoError(t, err)
+	assert.Equal(t, "20240216", strings.TrimSpace(string(get)))
+	get, err = getBody("api/nextdate?now=20240126&date=20240126&repeat=w+5&except=oops")
+	assert.NoError(t, err)
+	_, err = time.Parse("20060102", strings.TrimSpace(string(get)))
+	assert.Error(t, err)
+	// Ошибка разбора указывает группу и позицию неверного значения.
+	get, err = getBody("api/nextdate?now=20240126&date=20240126&repeat=m+1,32")
+	assert.NoError(t, err)
+	assert.Contains(t, string(get), "группа 2, позиция 5")
+	get, err = getBody("api/nextdate?now=20240126&date=20240126&repeat=" + url.QueryEscape("d 1; k 2"))
+	assert.NoError(t, err)
+	assert.Contains(t, string(get), "часть 2, группа 1, позиция 6")
+}
+
+func TestNextDates(t *testing.T) {
+	get := func(query string) map[string]any {
+		body, err := getBody("api/nextdates?now=20240126&date=20240126&" + query)
+		assert.NoError(t, err)
+		var m map[string]any
+		assert.NoError(t, json.Unmarshal(body, &m))
+		return m
+	}
+	want := []any{"20240129", "20240202", "20240205"}
+	assert.Equal(t, want, get("repeat=w+1,5&count=3")["dates"])
+	assert.Equal(t, want, get("repeat=w+1,5&until=20240205")["dates"])
+	assert.Len(t, get("repeat=d+1")["dates"], 10)
+	assert.Len(t, get("repeat=d+1&until=20300101")["dates"], 100)
+	assert.Equal(t, []any{"20240127"}, get("repeat=" + url.QueryEscape("RRULE:FREQ=DAILY;UNTIL=20240127"))["dates"])
+	assert.NotEmpty(t, get("repeat=d+1&count=1000")["error"])
+	assert.NotEmpty(t, get("repeat=k+1")["error"])
+	assert.Equal(t, []any{"20240128", "20240201", "20240204"},
+		get("repeat=" + url.QueryEscape("m 1; w 7") + "&count=3")["dates"])
 }
@@ -0,0 +1,57 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTaskPages(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	want := map[string]bool{}
+	for i := 0; i < 25; i++ {
+		id := addTask(t, task{date: now.AddDate(0, 0, i%4).Format(`20060102`), title: fmt.Sprintf("Задача %d", i)})
+		want[id] = true
+	}
+	assert.Len(t, getTasks(t, ""), 10)
+
+	got := map[string]bool{}
+	cursor := ""
+	for pages := 1; ; pages++ {
+		require.LessOrEqual(t, pages, 3)
+		ret, err := postJSON("api/tasks?limit=10&cursor="+url.QueryEscape(cursor), nil, http.MethodGet)
+		require.NoError(t, err)
+		assert.Equal(t, float64(25), ret["total"])
+		tasks, _ := ret["tasks"].([]any)
+		for _, v := range tasks {
+			task, _ := v.(map[string]any)
+			id := fmt.Sprint(task["id"])
+			assert.False(t, got[id], "задача %s повторяется", id)
+			got[id] = true
+		}
+		next, _ := ret["next_cursor"].(string)
+		if len(next) == 0 {
+			assert.Equal(t, 3, pages)
+			break
+		}
+		cursor = next
+	}
+	assert.Equal(t, want, got)
+
+	for _, query := range []string{"limit=0", "limit=101", "limit=abc", "cursor=abc"} {
+		ret, err := postJSON("api/tasks?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для '%s'", query)
+	}
+}
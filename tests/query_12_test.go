@@ -0,0 +1,54 @@
+package tests
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchQuery(t *testing.T) {
+	if !Search {
+		return
+	}
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	addTask(t, task{date: now.AddDate(0, 0, 1).Format(`20060102`), title: "Годовой отчёт",
+		comment: "черновик", repeat: "y"})
+	addTask(t, task{date: now.AddDate(0, 0, 3).Format(`20060102`), title: "Квартальный отчёт", repeat: "w 1"})
+	addTask(t, task{date: now.AddDate(0, 0, 5).Format(`20060102`), title: "Отчёт для банка"})
+
+	for query, count := range map[string]int{
+		"title:отчёт repeat:w":                                1,
+		"title:отчёт -comment:черновик":                       2,
+		"отчёт after:tomorrow":                                2,
+		"before:" + now.AddDate(0, 0, 4).Format(`02.01.2006`): 2,
+		"repeat:y OR repeat:w":                                2,
+		"-repeat:*":                                           1,
+		`title:"отчёт для"`:                                   1,
+		"Отчёт: банк":                                         1,
+		"отчёт - банк":                                        1,
+		"title:":                                              0,
+		"size:5":                                              0,
+		"-":                                                   3,
+	} {
+		assert.Len(t, getTasks(t, url.QueryEscape(query)), count, query)
+	}
+
+	for _, query := range []string{"(отчёт", "отчёт)", `title:"отчёт`, "отчёт OR"} {
+		ret, err := postJSON("api/tasks?search="+url.QueryEscape(query), nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для запроса '%s'", query)
+	}
+
+	ret, err := postJSON("api/tasks/syntax", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["operators"])
+}
@@ -0,0 +1,65 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRevisions(t *testing.T) {
+	revisions := func(id string) []map[string]any {
+		ret, err := postJSON("api/task/revisions?id="+id, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.Empty(t, ret["error"])
+		list, _ := ret["revisions"].([]any)
+		var res []map[string]any
+		for _, v := range list {
+			r, _ := v.(map[string]any)
+			res = append(res, r)
+		}
+		return res
+	}
+
+	today := time.Now().Format(`20060102`)
+	id := addTask(t, task{date: today, title: "Позвонить врачу", comment: "до обеда"})
+	ret, err := postJSON("api/task", map[string]any{"id": id, "date": today, "title": "Позвонить стоматологу",
+		"comment": "до обеда"}, http.MethodPut)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+
+	list := revisions(id)
+	if !assert.Len(t, list, 3) {
+		return
+	}
+	assert.Equal(t, "create", list[0]["action"])
+	assert.Nil(t, list[0]["before"])
+	assert.Equal(t, "update", list[1]["action"])
+	assert.Equal(t, []any{map[string]any{"field": "title", "before": "Позвонить врачу",
+		"after": "Позвонить стоматологу"}}, list[1]["changes"])
+	assert.Equal(t, "delete", list[2]["action"])
+	assert.NotEmpty(t, list[2]["changed_at"])
+
+	ret, err = postJSON(fmt.Sprintf("api/task/revert?id=%s&revision=%v", id, list[0]["id"]), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Empty(t, ret["error"])
+	assert.Equal(t, "Позвонить врачу", ret["title"])
+	assert.Len(t, revisions(id), 4)
+
+	for _, path := range []string{"api/task/revert?id=" + id, "api/task/revert?id=" + id + "&revision=1000000"} {
+		ret, err := postJSON(path, nil, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для %s", path)
+	}
+	ret, err = postJSON("api/task/revisions", nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret["error"])
+}
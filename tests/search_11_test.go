@@ -0,0 +1,43 @@
+package tests
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFullTextSearch(t *testing.T) {
+	if !Search {
+		return
+	}
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	date := time.Now().AddDate(0, 0, 1).Format(`20060102`)
+	addTask(t, task{date: date, title: "Позвонить бухгалтеру", comment: "Уточнить про отчёт за квартал"})
+	addTask(t, task{date: time.Now().AddDate(0, 0, 2).Format(`20060102`), title: "Квартальный ОТЧЁТ",
+		comment: "Отправить в налоговую"})
+	addTask(t, task{date: date, title: "Купить молоко"})
+
+	tasks := getTasks(t, url.QueryEscape("отчёт"))
+	if assert.Len(t, tasks, 2) {
+		// Совпадение в заголовке важнее совпадения в комментарии, хотя задача позже по дате.
+		assert.Equal(t, "Квартальный ОТЧЁТ", tasks[0]["title"])
+		assert.Contains(t, tasks[0]["snippet"], "<mark>ОТЧЁТ</mark>")
+		assert.Contains(t, tasks[1]["snippet"], "<mark>отчёт</mark>")
+	}
+	tasks = getTasks(t, url.QueryEscape("кварт отч"))
+	assert.Len(t, tasks, 2)
+	tasks = getTasks(t, url.QueryEscape("молоко бухгалтер"))
+	assert.Empty(t, tasks)
+
+	_, err = db.Exec("UPDATE scheduler SET title = 'Купить кефир' WHERE title = 'Купить молоко'")
+	assert.NoError(t, err)
+	assert.Empty(t, getTasks(t, url.QueryEscape("молоко")))
+	assert.Len(t, getTasks(t, url.QueryEscape("КЕФИР")), 1)
+}
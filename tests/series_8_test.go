@@ -0,0 +1,222 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSeries(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+
+	ret, err := postJSON("api/task", map[string]any{
+		"date":   now.Format(`20060102`),
+		"title":  "Лечебная физкультура",
+		"repeat": "d 3",
+		"until":  now.AddDate(0, 0, -1).Format(`20060102`),
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	_, ok := ret["error"]
+	assert.True(t, ok, "Ожидается ошибка для даты окончания раньше даты задачи")
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":      now.AddDate(0, 0, 1).Format(`20060102`),
+		"title":     "Лечебная физкультура",
+		"repeat":    "ooops",
+		"remaining": 2,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	_, ok = ret["error"]
+	assert.True(t, ok, "Ожидается ошибка для недопустимого правила повторения")
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":      now.AddDate(0, 0, 1).Format(`20060102`),
+		"title":     "Лечебная физкультура",
+		"repeat":    "d 3",
+		"remaining": 2,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, now.AddDate(0, 0, 4).Format(`20060102`), task.Date)
+	assert.Equal(t, 1, task.Remaining)
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	ret, err = postJSON("api/task", map[string]any{
+		"date":   now.AddDate(0, 0, 1).Format(`20060102`),
+		"title":  "Полить цветы",
+		"repeat": "d 3",
+		"until":  now.AddDate(0, 0, 3).Format(`20060102`),
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+
+	// COUNT отсчитывается от прошедшей даты начала серии, а не от даты, на которую перенесена задача.
+	start := now.AddDate(0, 0, -10)
+	ret, err = postJSON("api/task", map[string]any{
+		"date":   start.Format(`20060102`),
+		"title":  "Пить таблетки",
+		"repeat": "RRULE:FREQ=DAILY;COUNT=15",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id = fmt.Sprint(ret["id"])
+	task = Task{}
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	date, err := time.Parse(`20060102`, task.Date)
+	assert.NoError(t, err)
+	last, _ := time.Parse(`20060102`, start.AddDate(0, 0, 14).Format(`20060102`))
+	assert.Equal(t, int(last.Sub(date).Hours()/24)+1, task.Remaining)
+}
+
+func TestExclusions(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	date := now.AddDate(0, 0, 1)
+	id := addTask(t, task{
+		date:   date.Format(`20060102`),
+		title:  "Планёрка",
+		repeat: "d 1",
+	})
+
+	ret, err := postJSON("api/task/exclusions?id="+id+"&date="+date.Format(`20060102`), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, date.AddDate(0, 0, 1).Format(`20060102`), task.Date)
+
+	ret, err = postJSON("api/task/exclusions?id="+id+"&date="+date.AddDate(0, 0, 2).Format(`20060102`), nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, date.AddDate(0, 0, 3).Format(`20060102`), task.Date)
+
+	ret, err = postJSON("api/task/exclusions?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Len(t, ret["dates"], 2)
+
+	ret, err = postJSON("api/task/exclusions?id="+id+"&date="+date.Format(`20060102`), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/exclusions?id="+id+"&date="+date.Format(`20060102`), nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.NotEmpty(t, ret)
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/trash?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var count int
+	err = db.Get(&count, `SELECT count(*) FROM scheduler_exclusions WHERE task_id=?`, id)
+	assert.NoError(t, err)
+	assert.Zero(t, count)
+}
+
+func TestAfterDone(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	now := time.Now()
+	ret, err := postJSON("api/task", map[string]any{
+		"date":       now.AddDate(0, 0, 3).Format(`20060102`),
+		"title":      "Полить цветы",
+		"repeat":     "d 7",
+		"after_done": true,
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodGet)
+	assert.NoError(t, err)
+	assert.Equal(t, true, ret["after_done"])
+
+	ret, err = postJSON("api/task/done?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.True(t, task.AfterDone)
+	assert.Equal(t, now.AddDate(0, 0, 7).Format(`20060102`), task.Date)
+
+	get, err := getBody("api/nextdate?now=20240126&date=20240101&repeat=d+7&after_done=true")
+	assert.NoError(t, err)
+	assert.Equal(t, "20240202", string(get))
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+}
+
+func TestTimeZone(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	loc, err := time.LoadLocation("Pacific/Kiritimati")
+	assert.NoError(t, err)
+	today := time.Now().In(loc).Format(`20060102`)
+	ret, err := postJSON("api/task", map[string]any{
+		"title": "Созвон с командой",
+		"time":  "9:30",
+		"tz":    "Pacific/Kiritimati",
+	}, http.MethodPost)
+	assert.NoError(t, err)
+	id := fmt.Sprint(ret["id"])
+
+	var task Task
+	err = db.Get(&task, `SELECT * FROM scheduler WHERE id=?`, id)
+	assert.NoError(t, err)
+	assert.Equal(t, today, task.Date)
+	assert.Equal(t, "09:30", task.Time)
+	assert.Equal(t, "Pacific/Kiritimati", task.TZ)
+
+	for _, v := range []map[string]any{
+		{"title": "Задача", "time": "25:00"},
+		{"title": "Задача", "tz": "Mars/Olympus"},
+	} {
+		ret, err = postJSON("api/task", v, http.MethodPost)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для %v", v)
+	}
+
+	get, err := getBody("api/nextdate?date=20240101&repeat=d+1&tz=Pacific/Kiritimati")
+	assert.NoError(t, err)
+	next, err := time.Parse(`20060102`, today)
+	assert.NoError(t, err)
+	assert.Equal(t, next.AddDate(0, 0, 1).Format(`20060102`), string(get))
+
+	ret, err = postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+}
@@ -0,0 +1,60 @@
+package tests
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSortFilter(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	now := time.Now()
+	date := func(days int) string {
+		return now.AddDate(0, 0, days).Format(`20060102`)
+	}
+	addTask(t, task{date: date(2), title: "Бюджет"})
+	addTask(t, task{date: date(1), title: "Аренда", repeat: "d 5"})
+	addTask(t, task{date: date(3), title: "Встреча", repeat: "w 1"})
+	_, err = db.Exec(`INSERT INTO scheduler (date, title, comment, repeat) VALUES (?, 'Просрочено', '', '')`, date(-2))
+	assert.NoError(t, err)
+
+	list := func(query string) []string {
+		body, err := requestJSON("api/tasks?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		var m map[string][]map[string]string
+		assert.NoError(t, json.Unmarshal(body, &m))
+		var titles []string
+		for _, task := range m["tasks"] {
+			titles = append(titles, task["title"])
+		}
+		return titles
+	}
+	for query, want := range map[string][]string{
+		"":                                 {"Просрочено", "Аренда", "Бюджет", "Встреча"},
+		"sort=-date":                       {"Встреча", "Бюджет", "Аренда", "Просрочено"},
+		"sort=title":                       {"Аренда", "Бюджет", "Встреча", "Просрочено"},
+		"sort=created":                     {"Бюджет", "Аренда", "Встреча", "Просрочено"},
+		"kind=recurring":                   {"Аренда", "Встреча"},
+		"kind=once&sort=title":             {"Бюджет", "Просрочено"},
+		"overdue=true":                     {"Просрочено"},
+		"from=today&to=" + date(2):         {"Аренда", "Бюджет"},
+		"from=tomorrow&kind=once":          {"Бюджет"},
+		"to=today&sort=-date&overdue=true": {"Просрочено"},
+	} {
+		assert.Equal(t, want, list(query), query)
+	}
+
+	for _, query := range []string{"sort=size", "kind=daily", "overdue=maybe", "from=32.01.2026"} {
+		ret, err := postJSON("api/tasks?"+query, nil, http.MethodGet)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для '%s'", query)
+	}
+}
@@ -0,0 +1,73 @@
+package tests
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrash(t *testing.T) {
+	db := openDB(t)
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM scheduler")
+	assert.NoError(t, err)
+
+	trash := func() []string {
+		ret, err := postJSON("api/trash", nil, http.MethodGet)
+		assert.NoError(t, err)
+		tasks, _ := ret["tasks"].([]any)
+		var ids []string
+		for _, v := range tasks {
+			task, _ := v.(map[string]any)
+			assert.NotEmpty(t, task["deleted_at"])
+			ids = append(ids, fmt.Sprint(task["id"]))
+		}
+		return ids
+	}
+
+	id := addTask(t, task{date: time.Now().Format(`20060102`), title: "Удаляемая задача"})
+	done := addTask(t, task{date: time.Now().Format(`20060102`), title: "Выполненная задача"})
+	ret, err := postJSON("api/task?id="+id, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	ret, err = postJSON("api/task/done?id="+done, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	notFoundTask(t, id)
+	notFoundTask(t, done)
+	assert.Empty(t, getTasks(t, ""))
+	assert.ElementsMatch(t, []string{id, done}, trash())
+
+	var deleted string
+	assert.NoError(t, db.Get(&deleted, `SELECT deleted_at FROM scheduler WHERE id = ?`, id))
+	assert.NotEmpty(t, deleted)
+
+	ret, err = postJSON("api/trash/restore?id="+id, nil, http.MethodPost)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Len(t, getTasks(t, ""), 1)
+	assert.Equal(t, []string{done}, trash())
+
+	ret, err = postJSON("api/trash?id="+done, nil, http.MethodDelete)
+	assert.NoError(t, err)
+	assert.Empty(t, ret)
+	assert.Empty(t, trash())
+	var count int
+	assert.NoError(t, db.Get(&count, `SELECT count(*) FROM scheduler WHERE id = ?`, done))
+	assert.Zero(t, count)
+
+	for _, req := range []struct{ path, method string }{
+		{"api/trash/restore?id=" + id, http.MethodPost},
+		{"api/trash/restore?id=" + done, http.MethodPost},
+		{"api/trash?id=" + id, http.MethodDelete},
+		{"api/trash", http.MethodDelete},
+	} {
+		ret, err := postJSON(req.path, nil, req.method)
+		assert.NoError(t, err)
+		assert.NotEmpty(t, ret["error"], "ожидается ошибка для %s %s", req.method, req.path)
+	}
+}
@@ -0,0 +1,140 @@
+package tests
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/cookiejar"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// requestIfMatch выполняет запрос с заголовком If-Match и возвращает ответ с прочитанным json-телом.
+func requestIfMatch(t *testing.T, apipath string, values map[string]any, method, match string) (*http.Response,
+	map[string]any) {
+	var data []byte
+	if len(values) > 0 {
+		var err error
+		data, err = json.Marshal(values)
+		assert.NoError(t, err)
+	}
+	req, err := http.NewRequest(method, getURL(apipath), bytes.NewBuffer(data))
+	if !assert.NoError(t, err) {
+		return nil, nil
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if len(match) > 0 {
+		req.Header.Set("If-Match", match)
+	}
+	client := &http.Client{}
+	if len(Token) > 0 {
+		jar, err := cookiejar.New(nil)
+		assert.NoError(t, err)
+		jar.SetCookies(req.URL, []*http.Cookie{{Name: "token", Value: Token}})
+		client.Jar = jar
+	}
+	resp, err := client.Do(req)
+	if !assert.NoError(t, err) {
+		return nil, nil
+	}
+	defer resp.Body.Close()
+	var m map[string]any
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&m))
+	return resp, m
+}
+
+func TestVersion(t *testing.T) {
+	now := time.Now()
+	today := now.Format(`20060102`)
+	id := addTask(t, task{date: today, title: "Полить цветы", repeat: "d 3"})
+
+	resp, ret := requestIfMatch(t, "api/task?id="+id, nil, http.MethodGet, "")
+	if resp == nil {
+		return
+	}
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"1"`, resp.Header.Get("ETag"))
+	assert.Equal(t, "Полить цветы", ret["title"])
+
+	update := map[string]any{"id": id, "date": today, "title": "Полить фикус", "repeat": "d 3"}
+	resp, ret = requestIfMatch(t, "api/task", update, http.MethodPut, `"1"`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, ret)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	// Обновление и выполнение по устаревшей версии отклоняются.
+	update["title"] = "Полить кактус"
+	resp, ret = requestIfMatch(t, "api/task", update, http.MethodPut, `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.NotEmpty(t, ret["error"])
+	resp, ret = requestIfMatch(t, "api/task/done?id="+id, nil, http.MethodPost, `"1"`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.NotEmpty(t, ret["error"])
+
+	resp, ret = requestIfMatch(t, "api/task?id="+id, nil, http.MethodGet, "")
+	assert.Equal(t, "Полить фикус", ret["title"])
+	assert.Equal(t, today, ret["date"])
+
+	// Выполнение по актуальной версии переносит задачу ровно один раз.
+	resp, ret = requestIfMatch(t, "api/task/done?id="+id, nil, http.MethodPost, `"2"`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, ret)
+	resp, ret = requestIfMatch(t, "api/task/done?id="+id, nil, http.MethodPost, `"2"`)
+	assert.Equal(t, http.StatusPreconditionFailed, resp.StatusCode)
+	assert.NotEmpty(t, ret["error"])
+
+	resp, ret = requestIfMatch(t, "api/task?id="+id, nil, http.MethodGet, "")
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	assert.Equal(t, now.AddDate(0, 0, 3).Format(`20060102`), ret["date"])
+}
+
+func TestVersionConcurrent(t *testing.T) {
+	today := time.Now().Format(`20060102`)
+	id := addTask(t, task{date: today, title: "Принять витамины", repeat: "d 1"})
+	resp, _ := requestIfMatch(t, "api/task?id="+id, nil, http.MethodGet, "")
+	if resp == nil {
+		return
+	}
+	match := resp.Header.Get("ETag")
+
+	// parallel выполняет n одинаковых запросов одновременно и возвращает коды ответов.
+	parallel := func(n int, path, method, match string) []int {
+		codes := make([]int, n)
+		var wg sync.WaitGroup
+		for i := range codes {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if resp, _ := requestIfMatch(t, path, nil, method, match); resp != nil {
+					codes[i] = resp.StatusCode
+				}
+			}()
+		}
+		wg.Wait()
+		return codes
+	}
+
+	// Из одновременных выполнений одной версии задачи применяется ровно одно.
+	ok := 0
+	for _, code := range parallel(12, "api/task/done?id="+id, http.MethodPost, match) {
+		if code == http.StatusOK {
+			ok++
+			continue
+		}
+		assert.Contains(t, []int{http.StatusConflict, http.StatusPreconditionFailed}, code)
+	}
+	assert.Equal(t, 1, ok)
+	resp, ret := requestIfMatch(t, "api/task?id="+id, nil, http.MethodGet, "")
+	assert.Equal(t, time.Now().AddDate(0, 0, 1).Format(`20060102`), ret["date"])
+
+	// Без If-Match выполнения не должны завершаться ошибкой хранилища.
+	for _, code := range parallel(12, "api/task/done?id="+id, http.MethodPost, "") {
+		assert.Contains(t, []int{http.StatusOK, http.StatusConflict}, code)
+	}
+	for _, code := range parallel(30, "api/task?id="+id, http.MethodGet, "") {
+		assert.Equal(t, http.StatusOK, code)
+	}
+}